@@ -1,8 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -12,7 +14,9 @@ var DB *sql.DB
 func InitDB() {
 	var err error
 
-	DB, err = sql.Open("sqlite3", "api.db")
+	// sqlite ignores the foreign keys (and their ON DELETE) unless they are turned on for every
+	// connection, and without a busy timeout concurrent writers fail with "database is locked"
+	DB, err = sql.Open("sqlite3", "api.db?_foreign_keys=on&_busy_timeout=5000")
 
 	if err != nil {
 		panic(fmt.Sprintf("Could not connect to database: %v", err))
@@ -31,9 +35,11 @@ func createTables() {
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		first_name VARCHAR(40) NOT NULL,
 		last_name VARCHAR(40) NOT NULL,
-		email TEXT UNIQUE NOT NULL,
+		email TEXT NOT NULL,
 		password TEXT NOT NULL,
-		username TEXT NOT NULL
+		username TEXT NOT NULL,
+		is_admin BOOLEAN NOT NULL DEFAULT 0,
+		deleted_at DATETIME
 	)
 	`
 
@@ -43,11 +49,27 @@ func createTables() {
 		panic(fmt.Sprintf("Could not create users table %v", err))
 	}
 
+	addColumnIfMissing("users", "is_admin", "BOOLEAN NOT NULL DEFAULT 0")
+	// users, categories and tasks go to the trash first, the trash purge job deletes them for good
+	addColumnIfMissing("users", "deleted_at", "DATETIME")
+
+	// the email only has to be unique among the users that are not in the trash,
+	// older databases have the UNIQUE on the column and are rebuilt without it
+	rebuildTableIfContains("users", "email TEXT UNIQUE", createUserTable)
+
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS users_email_active ON users(email) WHERE deleted_at IS NULL`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create users email index %v", err))
+	}
+
 	createCategoriesTable := `
 		CREATE TABLE IF NOT EXISTS categories (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			title VARCHAR(40) UNIQUE NOT NULL,
-			description VARCHAR(40)
+			title VARCHAR(40) NOT NULL,
+			description VARCHAR(40),
+			deleted_at DATETIME,
+			version INTEGER NOT NULL DEFAULT 1
 		)
 	`
 
@@ -57,6 +79,20 @@ func createTables() {
 		panic(fmt.Sprintf("Could not create categories table %v", err))
 	}
 
+	addColumnIfMissing("categories", "deleted_at", "DATETIME")
+
+	// every write bumps the version, it is the ETag of the category and the task
+	addColumnIfMissing("categories", "version", "INTEGER NOT NULL DEFAULT 1")
+
+	// like the user emails, a title is free again once its category is in the trash
+	rebuildTableIfContains("categories", "title VARCHAR(40) UNIQUE", createCategoriesTable)
+
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS categories_title_active ON categories(title) WHERE deleted_at IS NULL`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create categories title index %v", err))
+	}
+
 	createTasksTable := `
 		CREATE TABLE IF NOT EXISTS tasks (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -65,10 +101,19 @@ func createTables() {
 			created_at DATE DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATE DEFAULT CURRENT_TIMESTAMP,
 			due_date DATE NOT NULL,
-			priority TEXT NOT NULL CHECK(priority IN ('low', 'medium', 'high')),
-			status TEXT NOT NULL CHECK(status IN ('todo', 'in-progress', 'done')),
+			priority TEXT NOT NULL,
+			status TEXT NOT NULL,
 			category_id INTEGER,
-			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+			estimate_minutes INTEGER NOT NULL DEFAULT 0,
+			board_rank TEXT NOT NULL DEFAULT '',
+			responded_at DATETIME,
+			resolved_at DATETIME,
+			parent_id INTEGER,
+			deleted_at DATETIME,
+			archived_at DATETIME,
+			version INTEGER NOT NULL DEFAULT 1,
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+			FOREIGN KEY (parent_id) REFERENCES tasks(id) ON DELETE SET NULL
 		)
 	`
 
@@ -78,6 +123,82 @@ func createTables() {
 		panic(fmt.Sprintf("Could not create tasks table %v", err))
 	}
 
+	// columns added after the first release, CREATE TABLE IF NOT EXISTS won't add them to old databases
+	addColumnIfMissing("tasks", "estimate_minutes", "INTEGER NOT NULL DEFAULT 0")
+	addColumnIfMissing("tasks", "board_rank", "TEXT NOT NULL DEFAULT ''")
+	addColumnIfMissing("tasks", "responded_at", "DATETIME")
+	addColumnIfMissing("tasks", "resolved_at", "DATETIME")
+	addColumnIfMissing("tasks", "parent_id", "INTEGER REFERENCES tasks(id) ON DELETE SET NULL")
+	addColumnIfMissing("tasks", "deleted_at", "DATETIME")
+	addColumnIfMissing("tasks", "archived_at", "DATETIME")
+	addColumnIfMissing("tasks", "version", "INTEGER NOT NULL DEFAULT 1")
+
+	// statuses and priorities live in their own tables now, old databases still have them in CHECKs
+	rebuildTableIfContains("tasks", "CHECK(", createTasksTable)
+
+	// tasks without a category used to keep 0, the foreign key only takes NULL
+	_, err = DB.Exec(`UPDATE tasks SET category_id = NULL WHERE category_id = 0`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not clear the empty task categories %v", err))
+	}
+
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS tasks_board_rank ON tasks(status, board_rank)`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create tasks board index %v", err))
+	}
+
+	// the workflow statuses, tasks refer to them by name. The defaults are only
+	// added to an empty table so deleted ones don't come back
+	createStatusesTable := `
+		CREATE TABLE IF NOT EXISTS statuses (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			color TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			category TEXT NOT NULL CHECK(category IN ('open', 'in_progress', 'closed'))
+		);
+		CREATE TABLE IF NOT EXISTS status_transitions (
+			from_status_id INTEGER NOT NULL,
+			to_status_id INTEGER NOT NULL,
+			PRIMARY KEY (from_status_id, to_status_id),
+			FOREIGN KEY (from_status_id) REFERENCES statuses(id) ON DELETE CASCADE,
+			FOREIGN KEY (to_status_id) REFERENCES statuses(id) ON DELETE CASCADE
+		);
+		INSERT INTO statuses(name, color, position, category)
+		SELECT 'todo', '#9E9E9E', 1, 'open' WHERE NOT EXISTS (SELECT 1 FROM statuses)
+		UNION ALL SELECT 'in-progress', '#2196F3', 2, 'in_progress' WHERE NOT EXISTS (SELECT 1 FROM statuses)
+		UNION ALL SELECT 'done', '#4CAF50', 3, 'closed' WHERE NOT EXISTS (SELECT 1 FROM statuses);
+	`
+	_, err = DB.Exec(createStatusesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create statuses table %v", err))
+	}
+
+	// priority levels, position 1 is the most urgent. The sla targets are in minutes and 0
+	// means no target, the defaults have none so old tasks don't show up as breached
+	createPrioritiesTable := `
+		CREATE TABLE IF NOT EXISTS priorities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			color TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			response_minutes INTEGER NOT NULL DEFAULT 0,
+			resolution_minutes INTEGER NOT NULL DEFAULT 0
+		);
+		INSERT INTO priorities(name, color, position)
+		SELECT 'high', '#F44336', 1 WHERE NOT EXISTS (SELECT 1 FROM priorities)
+		UNION ALL SELECT 'medium', '#FF9800', 2 WHERE NOT EXISTS (SELECT 1 FROM priorities)
+		UNION ALL SELECT 'low', '#8BC34A', 3 WHERE NOT EXISTS (SELECT 1 FROM priorities);
+	`
+	_, err = DB.Exec(createPrioritiesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create priorities table %v", err))
+	}
+
 	createTasksAssignees := `
 		CREATE TABLE IF NOT EXISTS tasks_assignees (
 			task_id INTEGER NOT NULL,
@@ -93,4 +214,551 @@ func createTables() {
 		panic(fmt.Sprintf("Could not create tasks_assignees table %v", err))
 	}
 
+	createLabelsTable := `
+		CREATE TABLE IF NOT EXISTS labels (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name VARCHAR(40) UNIQUE NOT NULL,
+			color VARCHAR(7) NOT NULL
+		)
+	`
+	_, err = DB.Exec(createLabelsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create labels table %v", err))
+	}
+
+	createTasksLabels := `
+		CREATE TABLE IF NOT EXISTS tasks_labels (
+			task_id INTEGER NOT NULL,
+			label_id INTEGER NOT NULL,
+			PRIMARY KEY (task_id, label_id),
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (label_id) REFERENCES labels(id) ON DELETE CASCADE
+		)
+	`
+	_, err = DB.Exec(createTasksLabels)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create tasks_labels table %v", err))
+	}
+
+	createChecklistItemsTable := `
+		CREATE TABLE IF NOT EXISTS checklist_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			text VARCHAR(250) NOT NULL,
+			position INTEGER NOT NULL,
+			checked BOOLEAN NOT NULL DEFAULT 0,
+			checked_by INTEGER,
+			checked_at DATETIME,
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (checked_by) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`
+	_, err = DB.Exec(createChecklistItemsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create checklist_items table %v", err))
+	}
+
+	createTimeEntriesTable := `
+		CREATE TABLE IF NOT EXISTS time_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			user_id INTEGER,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME,
+			minutes INTEGER NOT NULL DEFAULT 0,
+			note VARCHAR(250),
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`
+	_, err = DB.Exec(createTimeEntriesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create time_entries table %v", err))
+	}
+
+	// the logged time stays in the reports when its user is purged, older databases deleted it with the user
+	rebuildTableIfContains("time_entries", "user_id INTEGER NOT NULL", createTimeEntriesTable)
+
+	// a running timer has no ended_at yet and every user can only have one of them
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS time_entries_running_timer ON time_entries(user_id) WHERE ended_at IS NULL`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create time_entries index %v", err))
+	}
+
+	// audit_events is append-only, the triggers reject any change to a written event
+	createAuditEventsTable := `
+		CREATE TABLE IF NOT EXISTS audit_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			action TEXT NOT NULL CHECK(action IN ('create', 'update', 'delete', 'restore')),
+			actor_id INTEGER,
+			request_id TEXT,
+			changes TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS audit_events_entity ON audit_events(entity_type, entity_id);
+		CREATE INDEX IF NOT EXISTS audit_events_actor ON audit_events(actor_id);
+		CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+		BEGIN
+			SELECT RAISE(ABORT, 'audit_events is append-only');
+		END;
+		CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+		BEGIN
+			SELECT RAISE(ABORT, 'audit_events is append-only');
+		END;
+	`
+	_, err = DB.Exec(createAuditEventsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create audit_events table %v", err))
+	}
+
+	// older databases don't allow the restore action yet, the rebuild drops the indexes
+	// and triggers with the old table so they are created again
+	rebuildTableIfContains("audit_events", "'delete')", createAuditEventsTable)
+
+	_, err = DB.Exec(createAuditEventsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create audit_events table %v", err))
+	}
+
+	createCommentsTable := `
+		CREATE TABLE IF NOT EXISTS comments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			user_id INTEGER,
+			body TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`
+	_, err = DB.Exec(createCommentsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create comments table %v", err))
+	}
+
+	// the comments of a purged user stay on their tasks without an author
+	rebuildTableIfContains("comments", "user_id INTEGER NOT NULL", createCommentsTable)
+
+	createNotificationsTable := `
+		CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			task_id INTEGER,
+			actor_id INTEGER,
+			message TEXT NOT NULL,
+			read_at DATETIME,
+			created_at DATETIME NOT NULL,
+			event_id INTEGER,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS notifications_user ON notifications(user_id, read_at);
+	`
+	_, err = DB.Exec(createNotificationsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create notifications table %v", err))
+	}
+
+	addColumnIfMissing("notifications", "event_id", "INTEGER")
+
+	// an outbox event handed to the listener again notifies nobody twice
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS notifications_event ON notifications(event_id, user_id, type) WHERE event_id IS NOT NULL`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create notifications event index %v", err))
+	}
+
+	// a missing row means the notification type is enabled
+	createNotificationPreferencesTable := `
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			user_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL,
+			PRIMARY KEY (user_id, type),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`
+	_, err = DB.Exec(createNotificationPreferencesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create notification_preferences table %v", err))
+	}
+
+	createJobRunsTable := `
+		CREATE TABLE IF NOT EXISTS job_runs (
+			name TEXT PRIMARY KEY,
+			last_run_at DATETIME NOT NULL
+		)
+	`
+	_, err = DB.Exec(createJobRunsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create job_runs table %v", err))
+	}
+
+	// one row per sent reminder, the due date is part of the key so moving it re-arms the reminder
+	createTaskRemindersTable := `
+		CREATE TABLE IF NOT EXISTS task_reminders (
+			task_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			due_date DATE NOT NULL,
+			sent_at DATETIME NOT NULL,
+			PRIMARY KEY (task_id, user_id, kind, due_date),
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`
+	_, err = DB.Exec(createTaskRemindersTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create task_reminders table %v", err))
+	}
+
+	createDigestSettingsTable := `
+		CREATE TABLE IF NOT EXISTS digest_settings (
+			user_id INTEGER PRIMARY KEY,
+			frequency TEXT NOT NULL CHECK(frequency IN ('off', 'daily', 'weekly')),
+			timezone TEXT NOT NULL,
+			last_sent_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)
+	`
+	_, err = DB.Exec(createDigestSettingsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create digest_settings table %v", err))
+	}
+
+	createWebhooksTable := `
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			event_types TEXT NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT 1,
+			created_at DATETIME NOT NULL
+		)
+	`
+	_, err = DB.Exec(createWebhooksTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create webhooks table %v", err))
+	}
+
+	// the delivery queue, pending rows are picked up by the webhook worker once next_attempt_at has passed
+	createWebhookDeliveriesTable := `
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			webhook_id INTEGER NOT NULL,
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL CHECK(status IN ('pending', 'succeeded', 'failed')),
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at DATETIME NOT NULL,
+			response_code INTEGER,
+			last_error TEXT,
+			created_at DATETIME NOT NULL,
+			delivered_at DATETIME,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_queue ON webhook_deliveries(status, next_attempt_at);
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id);
+	`
+	_, err = DB.Exec(createWebhookDeliveriesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create webhook_deliveries table %v", err))
+	}
+
+	// events are written here in the same transaction as the change and published after the commit
+	createOutboxEventsTable := `
+		CREATE TABLE IF NOT EXISTS outbox_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			published_at DATETIME,
+			failed_at DATETIME,
+			last_error TEXT
+		);
+		CREATE INDEX IF NOT EXISTS outbox_events_pending ON outbox_events(published_at, id);
+	`
+	_, err = DB.Exec(createOutboxEventsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create outbox_events table %v", err))
+	}
+
+	// events some subscriber gave up on are kept with failed_at set instead of published_at
+	addColumnIfMissing("outbox_events", "failed_at", "DATETIME")
+	addColumnIfMissing("outbox_events", "last_error", "TEXT")
+
+	// what every subscriber did with an outbox event, the event is published once all of them delivered it
+	createOutboxDeliveriesTable := `
+		CREATE TABLE IF NOT EXISTS outbox_deliveries (
+			event_id INTEGER NOT NULL,
+			subscriber TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at DATETIME,
+			delivered_at DATETIME,
+			failed_at DATETIME,
+			PRIMARY KEY (event_id, subscriber),
+			FOREIGN KEY (event_id) REFERENCES outbox_events(id) ON DELETE CASCADE
+		);
+	`
+	_, err = DB.Exec(createOutboxDeliveriesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create outbox_deliveries table %v", err))
+	}
+
+	// custom fields are global when category_id is NULL, otherwise only tasks of that category have them.
+	// options holds the json array of choices of the select fields
+	createCustomFieldsTable := `
+		CREATE TABLE IF NOT EXISTS custom_fields (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			field_key TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL CHECK(type IN ('text', 'number', 'date', 'single_select', 'multi_select', 'user', 'url')),
+			options TEXT NOT NULL DEFAULT '[]',
+			required INTEGER NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL DEFAULT 0,
+			category_id INTEGER,
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+		)
+	`
+	_, err = DB.Exec(createCustomFieldsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create custom_fields table %v", err))
+	}
+
+	// the value is stored as json so json_extract can filter and sort on it
+	createTaskCustomValuesTable := `
+		CREATE TABLE IF NOT EXISTS task_custom_values (
+			task_id INTEGER NOT NULL,
+			field_id INTEGER NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (task_id, field_id),
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (field_id) REFERENCES custom_fields(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS task_custom_values_field ON task_custom_values(field_id);
+	`
+	_, err = DB.Exec(createTaskCustomValuesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create task_custom_values table %v", err))
+	}
+
+	// the lists of a template (assignees, labels, checklist, subtasks and custom fields) are json
+	createTaskTemplatesTable := `
+		CREATE TABLE IF NOT EXISTS task_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			title_pattern TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			priority TEXT NOT NULL,
+			category_id INTEGER,
+			due_in_days INTEGER NOT NULL DEFAULT 0,
+			assignees_ids TEXT NOT NULL DEFAULT '[]',
+			label_ids TEXT NOT NULL DEFAULT '[]',
+			checklist_items TEXT NOT NULL DEFAULT '[]',
+			subtasks TEXT NOT NULL DEFAULT '[]',
+			custom_fields TEXT NOT NULL DEFAULT '{}',
+			created_by INTEGER,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+			FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`
+	_, err = DB.Exec(createTaskTemplatesTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create task_templates table %v", err))
+	}
+
+	// templates are shared, they outlive the user who created them
+	rebuildTableIfContains("task_templates", "created_by INTEGER NOT NULL", createTaskTemplatesTable)
+
+	// responses of POST requests sent with an Idempotency-Key, a row without a status
+	// code is a request that is still running
+	createIdempotencyKeysTable := `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			scope TEXT NOT NULL,
+			idempotency_key TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			status_code INTEGER NOT NULL DEFAULT 0,
+			response_body BLOB,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (scope, idempotency_key)
+		)
+	`
+	_, err = DB.Exec(createIdempotencyKeysTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create idempotency_keys table %v", err))
+	}
+
+	createAttachmentsTable := `
+		CREATE TABLE IF NOT EXISTS attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			file_name TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			storage_key TEXT UNIQUE NOT NULL,
+			uploaded_by INTEGER,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			thumbnails_pending BOOLEAN NOT NULL DEFAULT 0,
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL
+		)
+	`
+	_, err = DB.Exec(createAttachmentsTable)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not create attachments table %v", err))
+	}
+
+	// images wait here for their thumbnails, so none are lost on a restart
+	addColumnIfMissing("attachments", "thumbnails_pending", "BOOLEAN NOT NULL DEFAULT 0")
+
+}
+
+func addColumnIfMissing(table, column, definition string) {
+	rows, err := DB.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", table))
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not read %s columns %v", table, err))
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+
+		if err != nil {
+			panic(fmt.Sprintf("Could not read %s columns %v", table, err))
+		}
+
+		if name == column {
+			return
+		}
+	}
+
+	rows.Close()
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not add %s.%s column %v", table, column, err))
+	}
+}
+
+// rebuildTableIfContains recreates the table from createQuery when its current definition still
+// has the given fragment, sqlite can't drop a constraint in place. Columns of the old table have to
+// exist in the new one, indexes are created after this runs
+func rebuildTableIfContains(table, fragment, createQuery string) {
+	var definition string
+
+	err := DB.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&definition)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not read %s definition %v", table, err))
+	}
+
+	if !strings.Contains(definition, fragment) {
+		return
+	}
+
+	rows, err := DB.Query(`SELECT name FROM pragma_table_info(?)`, table)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not read %s columns %v", table, err))
+	}
+
+	var columns []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+
+		if err != nil {
+			panic(fmt.Sprintf("Could not read %s columns %v", table, err))
+		}
+
+		columns = append(columns, name)
+	}
+
+	rows.Close()
+
+	newTable := table + "_new"
+	columnList := strings.Join(columns, ", ")
+
+	// the documented sqlite way: create the new table, copy, drop the old one and rename,
+	// renaming the old table instead would rewrite the foreign keys pointing at it.
+	// The foreign keys are off meanwhile, dropping the table would run its ON DELETEs
+	conn, err := DB.Conn(context.Background())
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not rebuild %s table %v", table, err))
+	}
+
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(), `PRAGMA foreign_keys = OFF`)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not rebuild %s table %v", table, err))
+	}
+
+	defer conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(context.Background(), nil)
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not rebuild %s table %v", table, err))
+	}
+
+	defer tx.Rollback()
+
+	statements := []string{
+		strings.Replace(createQuery, "CREATE TABLE IF NOT EXISTS "+table+" ", "CREATE TABLE "+newTable+" ", 1),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", newTable, columnList, columnList, table),
+		fmt.Sprintf("DROP TABLE %s", table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", newTable, table),
+	}
+
+	for _, statement := range statements {
+		_, err = tx.Exec(statement)
+
+		if err != nil {
+			panic(fmt.Sprintf("Could not rebuild %s table %v", table, err))
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		panic(fmt.Sprintf("Could not rebuild %s table %v", table, err))
+	}
 }
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Event is a domain event, the name is what the outbox stores it under
+type Event interface {
+	EventName() string
+}
+
+// a subscriber is known by its name in the outbox, so it has to stay the same
+// between releases or the events it already handled are handed to it again
+type subscriber struct {
+	name   string
+	handle func(eventId int64, event Event) error
+	queue  chan delivery // nil for synchronous subscribers
+}
+
+// delivery is one outbox event on its way to an async subscriber
+type delivery struct {
+	eventId int64
+	event   Event
+}
+
+var (
+	mutex       sync.RWMutex
+	subscribers = make(map[string][]subscriber)
+	decoders    = make(map[string]func([]byte) (Event, error))
+)
+
+// Subscribe runs the handler in the publishing goroutine, publishing waits for it
+// so it is the place for side effects that have to happen before the request returns.
+// A handler that fails is retried later with the same event, see Flush
+func Subscribe[T Event](name string, handler func(T) error) {
+	SubscribeWithID(name, func(_ int64, event T) error {
+		return handler(event)
+	})
+}
+
+// SubscribeWithID is Subscribe for handlers that need the outbox id of the event,
+// it stays the same on every retry so the receivers of the event can deduplicate it
+func SubscribeWithID[T Event](name string, handler func(eventId int64, event T) error) {
+	addSubscriber(name, handler, nil)
+}
+
+// SubscribeAsync runs the handler in its own goroutine, events are handled one at a time.
+// The event only counts as handled once the handler returns without an error
+func SubscribeAsync[T Event](name string, handler func(T) error) {
+	queue := make(chan delivery, 256)
+
+	go func() {
+		for next := range queue {
+			err := runHandler(next.event, func(event Event) error {
+				return handler(event.(T))
+			})
+
+			finishDelivery(next.eventId, name, err)
+		}
+	}()
+
+	addSubscriber(name, func(_ int64, event T) error {
+		return handler(event)
+	}, queue)
+}
+
+func addSubscriber[T Event](name string, handler func(int64, T) error, queue chan delivery) {
+	var zero T
+	eventName := zero.EventName()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, existing := range subscribers[eventName] {
+		if existing.name == name {
+			panic(fmt.Sprintf("%s is already subscribed to %s", name, eventName))
+		}
+	}
+
+	subscribers[eventName] = append(subscribers[eventName], subscriber{
+		name: name,
+		handle: func(eventId int64, event Event) error {
+			return handler(eventId, event.(T))
+		},
+		queue: queue,
+	})
+
+	// the outbox only keeps json, this is how it gets the typed event back
+	decoders[eventName] = func(payload []byte) (Event, error) {
+		var event T
+
+		err := json.Unmarshal(payload, &event)
+
+		return event, err
+	}
+}
+
+func subscribersOf(eventName string) []subscriber {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return subscribers[eventName]
+}
+
+// runHandler turns a panicking subscriber into an error, one broken subscriber must not stop the others
+func runHandler(event Event, handle func(Event) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("subscriber of %s panicked: %v", event.EventName(), recovered)
+		}
+	}()
+
+	return handle(event)
+}
+
+func decode(name string, payload []byte) (Event, bool, error) {
+	mutex.RLock()
+	decoder, ok := decoders[name]
+	mutex.RUnlock()
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	event, err := decoder(payload)
+
+	return event, true, err
+}
@@ -0,0 +1,309 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+// published events are kept for a while so a crash while handling them can be looked into
+const outboxRetention = 7 * 24 * time.Hour
+
+// only one goroutine at a time walks the outbox so an event is not handed to a subscriber twice
+var flushMutex sync.Mutex
+
+// the async deliveries sitting in a queue, the next Flush must not queue them again
+var (
+	inFlightMutex sync.Mutex
+	inFlight      = make(map[string]bool)
+)
+
+// Record writes the event to the outbox in the same transaction as the change,
+// call Flush once the transaction is committed to publish it
+func Record(tx *sql.Tx, event Event) error {
+	payload, err := json.Marshal(event)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO outbox_events(name, payload, created_at) VALUES(?, ?, ?)`, event.EventName(), string(payload), time.Now().UTC())
+
+	return err
+}
+
+// Flush hands the outbox events to the subscribers that did not handle them yet. Every subscriber
+// acknowledges on its own, a failing one is retried with a growing delay and given up after
+// OUTBOX_MAX_ATTEMPTS, the event then stays in the outbox as failed. An event is published once
+// every subscriber handled it, so handlers get it at least once but never lose it.
+// Subscribers must not call Flush themselves, it would wait on itself
+func Flush() {
+	flushMutex.Lock()
+	defer flushMutex.Unlock()
+
+	err := flushOutbox()
+
+	if err != nil {
+		log.Printf("could not flush the event outbox: %v", err)
+	}
+}
+
+func flushOutbox() error {
+	// events waiting for a retry stay pending, the cursor keeps them from being read again
+	var lastId int64
+
+	for {
+		rows, err := db.DB.Query(`SELECT id, name, payload FROM outbox_events WHERE published_at IS NULL AND failed_at IS NULL AND id > ? ORDER BY id LIMIT 100`, lastId)
+
+		if err != nil {
+			return err
+		}
+
+		type outboxEvent struct {
+			id      int64
+			name    string
+			payload string
+		}
+
+		var pending []outboxEvent
+
+		for rows.Next() {
+			var event outboxEvent
+
+			err = rows.Scan(&event.id, &event.name, &event.payload)
+
+			if err != nil {
+				rows.Close()
+				return err
+			}
+
+			pending = append(pending, event)
+		}
+
+		rows.Close()
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		for _, pendingEvent := range pending {
+			err = deliverOutboxEvent(pendingEvent.id, pendingEvent.name, pendingEvent.payload)
+
+			if err != nil {
+				return err
+			}
+
+			lastId = pendingEvent.id
+		}
+	}
+}
+
+// deliveryState is how far one subscriber got with one event
+type deliveryState struct {
+	delivered     bool
+	failed        bool
+	lastError     string
+	nextAttemptAt time.Time
+}
+
+func deliverOutboxEvent(eventId int64, name, payload string) error {
+	now := time.Now().UTC()
+
+	event, subscribed, err := decode(name, []byte(payload))
+
+	// it won't decode on a retry either
+	if err != nil {
+		_, err = db.DB.Exec(`UPDATE outbox_events SET failed_at = ?, last_error = ? WHERE id = ?`, now, "could not decode: "+err.Error(), eventId)
+		return err
+	}
+
+	if !subscribed {
+		_, err = db.DB.Exec(`UPDATE outbox_events SET published_at = ? WHERE id = ?`, now, eventId)
+		return err
+	}
+
+	states, err := deliveryStates(eventId)
+
+	if err != nil {
+		return err
+	}
+
+	finished := true
+	var failures []string
+
+	for _, subscriber := range subscribersOf(name) {
+		state := states[subscriber.name]
+
+		if !state.delivered && !state.failed && !now.Before(state.nextAttemptAt) {
+			if subscriber.queue != nil {
+				queueDelivery(subscriber, eventId, event)
+			} else {
+				handlerErr := runHandler(event, func(event Event) error {
+					return subscriber.handle(eventId, event)
+				})
+
+				state, err = recordDelivery(eventId, subscriber.name, handlerErr)
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if state.failed {
+			failures = append(failures, subscriber.name+": "+state.lastError)
+		} else if !state.delivered {
+			finished = false
+		}
+	}
+
+	if !finished {
+		return nil
+	}
+
+	// the dead letters stay in the outbox until someone looks into them
+	if len(failures) > 0 {
+		_, err = db.DB.Exec(`UPDATE outbox_events SET failed_at = ?, last_error = ? WHERE id = ?`, now, strings.Join(failures, "; "), eventId)
+		return err
+	}
+
+	_, err = db.DB.Exec(`UPDATE outbox_events SET published_at = ? WHERE id = ?`, now, eventId)
+
+	return err
+}
+
+func deliveryStates(eventId int64) (map[string]deliveryState, error) {
+	rows, err := db.DB.Query(`SELECT subscriber, delivered_at IS NOT NULL, failed_at IS NOT NULL, COALESCE(last_error, ''), next_attempt_at FROM outbox_deliveries WHERE event_id = ?`, eventId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	states := make(map[string]deliveryState)
+
+	for rows.Next() {
+		var subscriber string
+		var state deliveryState
+		var nextAttemptAt sql.NullTime
+
+		err = rows.Scan(&subscriber, &state.delivered, &state.failed, &state.lastError, &nextAttemptAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		state.nextAttemptAt = nextAttemptAt.Time
+		states[subscriber] = state
+	}
+
+	return states, nil
+}
+
+// recordDelivery acknowledges the event for the subscriber, or counts the failed attempt and
+// sets when the next one is due. The delay grows with the square of the attempts
+func recordDelivery(eventId int64, subscriber string, handlerErr error) (deliveryState, error) {
+	now := time.Now().UTC()
+
+	if handlerErr == nil {
+		_, err := db.DB.Exec(`
+			INSERT INTO outbox_deliveries(event_id, subscriber, attempts, delivered_at) VALUES(?, ?, 1, ?)
+			ON CONFLICT(event_id, subscriber) DO UPDATE SET attempts = attempts + 1, delivered_at = excluded.delivered_at, last_error = NULL, next_attempt_at = NULL
+		`, eventId, subscriber, now)
+
+		return deliveryState{delivered: true}, err
+	}
+
+	log.Printf("subscriber %s could not handle outbox event %d: %v", subscriber, eventId, handlerErr)
+
+	state := deliveryState{lastError: handlerErr.Error()}
+
+	var attempts int64
+
+	err := db.DB.QueryRow(`
+		INSERT INTO outbox_deliveries(event_id, subscriber, attempts, last_error) VALUES(?, ?, 1, ?)
+		ON CONFLICT(event_id, subscriber) DO UPDATE SET attempts = attempts + 1, last_error = excluded.last_error
+		RETURNING attempts
+	`, eventId, subscriber, state.lastError).Scan(&attempts)
+
+	if err != nil {
+		return state, err
+	}
+
+	if attempts >= utils.GetEnvInt64("OUTBOX_MAX_ATTEMPTS", 10) {
+		state.failed = true
+
+		_, err = db.DB.Exec(`UPDATE outbox_deliveries SET failed_at = ? WHERE event_id = ? AND subscriber = ?`, now, eventId, subscriber)
+
+		return state, err
+	}
+
+	state.nextAttemptAt = now.Add(time.Duration(attempts*attempts) * time.Minute)
+
+	_, err = db.DB.Exec(`UPDATE outbox_deliveries SET next_attempt_at = ? WHERE event_id = ? AND subscriber = ?`, state.nextAttemptAt, eventId, subscriber)
+
+	return state, err
+}
+
+func inFlightKey(eventId int64, subscriber string) string {
+	return fmt.Sprintf("%d/%s", eventId, subscriber)
+}
+
+// queueDelivery hands the event to an async subscriber unless it already has it,
+// when its queue is full the next Flush tries again
+func queueDelivery(subscriber subscriber, eventId int64, event Event) {
+	key := inFlightKey(eventId, subscriber.name)
+
+	inFlightMutex.Lock()
+	defer inFlightMutex.Unlock()
+
+	if inFlight[key] {
+		return
+	}
+
+	select {
+	case subscriber.queue <- delivery{eventId: eventId, event: event}:
+		inFlight[key] = true
+	default:
+	}
+}
+
+// finishDelivery is the acknowledgement of an async subscriber, the event is
+// published by the next Flush once the other subscribers are done as well
+func finishDelivery(eventId int64, subscriber string, handlerErr error) {
+	_, err := recordDelivery(eventId, subscriber, handlerErr)
+
+	if err != nil {
+		log.Printf("could not record the delivery of outbox event %d to %s: %v", eventId, subscriber, err)
+	}
+
+	inFlightMutex.Lock()
+	delete(inFlight, inFlightKey(eventId, subscriber))
+	inFlightMutex.Unlock()
+}
+
+// StartRelay publishes what was left in the outbox by the last run and keeps
+// retrying every minute, for the failed subscribers and the async acknowledgements
+func StartRelay() {
+	go func() {
+		for {
+			Flush()
+
+			_, err := db.DB.Exec(`DELETE FROM outbox_events WHERE published_at < ?`, time.Now().UTC().Add(-outboxRetention))
+
+			if err != nil {
+				log.Printf("could not clean up the event outbox: %v", err)
+			}
+
+			time.Sleep(time.Minute)
+		}
+	}()
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+// ScheduleAutoArchive archives the tasks that have been done for longer than
+// ARCHIVE_DONE_AFTER_DAYS (default 14, 0 turns the rule off), it checks every
+// ARCHIVE_INTERVAL_MINUTES (default 60)
+func ScheduleAutoArchive() {
+	doneAfter := time.Duration(utils.GetEnvInt64("ARCHIVE_DONE_AFTER_DAYS", 14)) * 24 * time.Hour
+	interval := time.Duration(utils.GetEnvInt64("ARCHIVE_INTERVAL_MINUTES", 60)) * time.Minute
+
+	if doneAfter <= 0 {
+		return
+	}
+
+	Schedule(Job{
+		Name:     "auto-archive",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			tasks, err := models.GetTasksDoneBefore(now.Add(-doneAfter))
+
+			if err != nil || len(tasks) == 0 {
+				return err
+			}
+
+			// nobody archives these, the audit trail shows no actor
+			return models.ArchiveTasks(tasks, now.UTC(), models.Actor{})
+		},
+	})
+}
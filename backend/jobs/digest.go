@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/mailer"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+//go:embed templates/digest.html templates/digest.txt
+var digestTemplates embed.FS
+
+var digestHTML = htmltemplate.Must(htmltemplate.ParseFS(digestTemplates, "templates/digest.html"))
+var digestText = texttemplate.Must(texttemplate.ParseFS(digestTemplates, "templates/digest.txt"))
+
+type digestTask struct {
+	Title    string
+	DueDate  string
+	Status   models.Status
+	Priority models.Priority
+}
+
+type digestActivity struct {
+	Time    string
+	Summary string
+}
+
+type digestData struct {
+	FirstName    string
+	Period       string
+	OpenTasks    []digestTask
+	OverdueTasks []digestTask
+	Activity     []digestActivity
+}
+
+// ScheduleDigests checks every 15 minutes who is due a digest, digests go out
+// after DIGEST_HOUR (default 8) in the user's own timezone, weekly ones on mondays
+func ScheduleDigests() {
+	digestHour := int(utils.GetEnvInt64("DIGEST_HOUR", 8))
+
+	Schedule(Job{
+		Name:     "email-digests",
+		Interval: 15 * time.Minute,
+		Run: func(now time.Time) error {
+			return sendDigests(now, digestHour)
+		},
+	})
+}
+
+func sendDigests(now time.Time, digestHour int) error {
+	recipients, err := models.GetDigestRecipients()
+
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		location, err := time.LoadLocation(recipient.Timezone)
+
+		if err != nil {
+			location = time.UTC
+		}
+
+		if !isDigestDue(recipient.DigestSettings, now.In(location), digestHour) {
+			continue
+		}
+
+		err = sendDigest(recipient, now, location)
+
+		if err != nil {
+			log.Printf("could not send the digest of user %d: %v", recipient.UserID, err)
+			continue
+		}
+
+		err = models.MarkDigestSent(recipient.UserID, now)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isDigestDue(settings models.DigestSettings, localNow time.Time, digestHour int) bool {
+	if localNow.Hour() < digestHour {
+		return false
+	}
+
+	if settings.Frequency == models.DigestWeekly && localNow.Weekday() != time.Monday {
+		return false
+	}
+
+	if settings.LastSentAt == nil {
+		return true
+	}
+
+	lastSent := settings.LastSentAt.In(localNow.Location())
+
+	return lastSent.Year() != localNow.Year() || lastSent.YearDay() != localNow.YearDay()
+}
+
+func sendDigest(recipient models.DigestRecipient, now time.Time, location *time.Location) error {
+	period := "daily"
+	since := now.AddDate(0, 0, -1)
+
+	if recipient.Frequency == models.DigestWeekly {
+		period = "weekly"
+		since = now.AddDate(0, 0, -7)
+	}
+
+	if recipient.LastSentAt != nil {
+		since = *recipient.LastSentAt
+	}
+
+	openTasks, err := models.GetOpenAssignedTasks(recipient.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	activity, err := models.GetAssignedTasksActivity(recipient.UserID, since, 20)
+
+	if err != nil {
+		return err
+	}
+
+	// nothing to say, don't send an empty email
+	if len(openTasks) == 0 && len(activity) == 0 {
+		return nil
+	}
+
+	data := digestData{
+		FirstName: recipient.FirstName,
+		Period:    period,
+	}
+
+	taskTitles := make(map[int64]string)
+
+	for _, task := range openTasks {
+		taskTitles[task.ID] = task.Title
+
+		item := digestTask{
+			Title:    task.Title,
+			DueDate:  task.DueDate.In(location).Format("Mon Jan 2 15:04"),
+			Status:   task.Status,
+			Priority: task.Priority,
+		}
+
+		if task.DueDate.Before(now) {
+			data.OverdueTasks = append(data.OverdueTasks, item)
+		}
+
+		data.OpenTasks = append(data.OpenTasks, item)
+	}
+
+	for _, event := range activity {
+		data.Activity = append(data.Activity, digestActivity{
+			Time:    event.CreatedAt.In(location).Format("Mon Jan 2 15:04"),
+			Summary: summarizeEvent(event, taskTitles),
+		})
+	}
+
+	var text, html bytes.Buffer
+
+	err = digestText.Execute(&text, data)
+
+	if err != nil {
+		return err
+	}
+
+	err = digestHTML.Execute(&html, data)
+
+	if err != nil {
+		return err
+	}
+
+	return mailer.Default.Send(mailer.Message{
+		To:      recipient.Email,
+		Subject: fmt.Sprintf("Your %s task digest: %d open, %d overdue", period, len(data.OpenTasks), len(data.OverdueTasks)),
+		Text:    text.String(),
+		HTML:    html.String(),
+	})
+}
+
+func summarizeEvent(event models.AuditEvent, taskTitles map[int64]string) string {
+	title, ok := taskTitles[event.EntityID]
+
+	if !ok {
+		title = fmt.Sprintf("task #%d", event.EntityID)
+	}
+
+	if event.Action != models.AuditUpdate {
+		return fmt.Sprintf("%s was %sd", title, event.Action)
+	}
+
+	var fields []string
+
+	for field := range event.Changes {
+		fields = append(fields, strings.ReplaceAll(field, "_", " "))
+	}
+
+	sort.Strings(fields)
+
+	return fmt.Sprintf("%s: %s changed", title, strings.Join(fields, ", "))
+}
@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+)
+
+// ScheduleIdempotencyCleanup deletes the saved responses once they can't be replayed anymore
+func ScheduleIdempotencyCleanup() {
+	Schedule(Job{
+		Name:     "idempotency-keys-cleanup",
+		Interval: time.Hour,
+		Run: func(now time.Time) error {
+			return models.DeleteIdempotencyKeysBefore(now.Add(-models.IdempotencyWindow()))
+		},
+	})
+}
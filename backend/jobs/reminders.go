@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/mailer"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+const (
+	ReminderChannelInApp = "in-app"
+	ReminderChannelEmail = "email"
+)
+
+// ScheduleReminders is configured with
+// REMINDER_INTERVAL_MINUTES (default 5), REMINDER_DUE_SOON_HOURS (default 24)
+// and REMINDER_CHANNELS, a comma separated list of in-app and email (default in-app)
+func ScheduleReminders() {
+	interval := time.Duration(utils.GetEnvInt64("REMINDER_INTERVAL_MINUTES", 5)) * time.Minute
+	dueSoonWindow := time.Duration(utils.GetEnvInt64("REMINDER_DUE_SOON_HOURS", 24)) * time.Hour
+	channels := utils.GetEnvList("REMINDER_CHANNELS", []string{ReminderChannelInApp})
+
+	Schedule(Job{
+		Name:     "due-date-reminders",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			return sendReminders(now, dueSoonWindow, channels)
+		},
+	})
+}
+
+func sendReminders(now time.Time, dueSoonWindow time.Duration, channels []string) error {
+	assignments, err := models.GetAssignmentsDueBefore(now.Add(dueSoonWindow))
+
+	if err != nil {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		kind := models.NotificationDueSoon
+		message := fmt.Sprintf("%q is due %s", assignment.Title, assignment.DueDate.Format("Jan 2 15:04 MST"))
+
+		if assignment.DueDate.Before(now) {
+			kind = models.NotificationOverdue
+			message = fmt.Sprintf("%q is overdue since %s", assignment.Title, assignment.DueDate.Format("Jan 2 15:04 MST"))
+		}
+
+		claimed, err := models.ClaimReminder(assignment.TaskID, assignment.UserID, kind, assignment.DueDate)
+
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		// one failing channel should not stop the others or the rest of the reminders
+		if slices.Contains(channels, ReminderChannelInApp) {
+			err = models.Notify([]int64{assignment.UserID}, models.Notification{
+				Type:    kind,
+				TaskID:  assignment.TaskID,
+				Message: message,
+			})
+
+			if err != nil {
+				log.Printf("could not send in-app reminder for task %d: %v", assignment.TaskID, err)
+			}
+		}
+
+		if slices.Contains(channels, ReminderChannelEmail) {
+			err = mailer.Default.Send(mailer.Message{
+				To:      assignment.Email,
+				Subject: message,
+				Text:    fmt.Sprintf("Hi %s,\n\n%s.\n", assignment.FirstName, message),
+			})
+
+			if err != nil {
+				log.Printf("could not send reminder email for task %d: %v", assignment.TaskID, err)
+			}
+		}
+	}
+
+	return nil
+}
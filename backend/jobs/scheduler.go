@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+)
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// Schedule runs the job every interval in its own goroutine, the last run is
+// kept in the database so a restart does not run every job again right away
+func Schedule(job Job) {
+	go func() {
+		lastRun, err := models.GetJobLastRun(job.Name)
+
+		if err != nil {
+			log.Printf("could not read the last run of job %s: %v", job.Name, err)
+		}
+
+		if wait := time.Until(lastRun.Add(job.Interval)); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		for {
+			runJob(job)
+			time.Sleep(job.Interval)
+		}
+	}()
+}
+
+func runJob(job Job) {
+	now := time.Now()
+
+	// a panicking job must not take the whole server down
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("job %s panicked: %v", job.Name, recovered)
+		}
+	}()
+
+	err := job.Run(now)
+
+	if err != nil {
+		log.Printf("job %s failed: %v", job.Name, err)
+	}
+
+	err = models.SaveJobRun(job.Name, now)
+
+	if err != nil {
+		log.Printf("could not save the run of job %s: %v", job.Name, err)
+	}
+}
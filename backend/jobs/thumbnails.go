@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/storage"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+var thumbnailQueue = make(chan int64, 100)
+
+// EnqueueThumbnails never blocks the upload request, the attachment is already saved
+// as pending so when the queue is full the next rescan picks it up
+func EnqueueThumbnails(attachmentId int64) {
+	select {
+	case thumbnailQueue <- attachmentId:
+	default:
+		log.Printf("thumbnail queue is full, attachment %d waits for the next rescan", attachmentId)
+	}
+}
+
+// StartThumbnailWorker generates the queued thumbnails, every few minutes and on start it
+// also goes over the pending attachments of the db, the queue only makes new uploads quick
+func StartThumbnailWorker() {
+	rescan := time.NewTicker(time.Duration(utils.GetEnvInt64("THUMBNAIL_RESCAN_MINUTES", 5)) * time.Minute)
+
+	go func() {
+		generatePendingThumbnails()
+
+		for {
+			select {
+			case attachmentId := <-thumbnailQueue:
+				generateThumbnails(attachmentId)
+			case <-rescan.C:
+				generatePendingThumbnails()
+			}
+		}
+	}()
+}
+
+func generatePendingThumbnails() {
+	attachmentIds, err := models.GetPendingThumbnails()
+
+	if err != nil {
+		log.Printf("could not get the pending thumbnails: %v", err)
+		return
+	}
+
+	for _, attachmentId := range attachmentIds {
+		generateThumbnails(attachmentId)
+	}
+}
+
+// generateThumbnails leaves the attachment pending when storage fails so it is retried,
+// images that can't be decoded never get thumbnails and the original is served instead
+func generateThumbnails(attachmentId int64) {
+	attachment, err := models.GetAttachment(attachmentId)
+
+	// deleted meanwhile or done by an earlier rescan
+	if err != nil || !attachment.ThumbnailsPending {
+		return
+	}
+
+	err = writeThumbnails(*attachment)
+
+	if errors.Is(err, errNoThumbnails) {
+		log.Printf("attachment %d gets no thumbnails: %v", attachmentId, err)
+	} else if err != nil {
+		log.Printf("could not generate thumbnails for attachment %d: %v", attachmentId, err)
+		return
+	}
+
+	err = attachment.ThumbnailsDone()
+
+	if err != nil {
+		log.Printf("could not mark the thumbnails of attachment %d: %v", attachmentId, err)
+	}
+}
+
+var errNoThumbnails = errors.New("image can't be decoded")
+
+// objectReader keeps the first error of the storage, the decoders would report it as a broken image
+type objectReader struct {
+	io.ReadSeeker
+	err error
+}
+
+func (reader *objectReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadSeeker.Read(p)
+
+	if err != nil && err != io.EOF && reader.err == nil {
+		reader.err = err
+	}
+
+	return n, err
+}
+
+func (reader *objectReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := reader.ReadSeeker.Seek(offset, whence)
+
+	if err != nil && reader.err == nil {
+		reader.err = err
+	}
+
+	return position, err
+}
+
+func writeThumbnails(attachment models.Attachment) error {
+	object, err := storage.Store.Open(attachment.StorageKey)
+
+	if err != nil {
+		return err
+	}
+
+	defer object.Close()
+
+	reader := &objectReader{ReadSeeker: object}
+
+	source, err := utils.DecodeImage(reader, utils.GetEnvInt64("THUMBNAIL_MAX_PIXELS", 40_000_000))
+
+	// a storage failure is retried by the next rescan
+	if reader.err != nil {
+		return reader.err
+	}
+
+	// formats the standard library can't decode (webp, svg...), broken files and images over the pixel cap
+	if err != nil {
+		return fmt.Errorf("%w: %v", errNoThumbnails, err)
+	}
+
+	flat := utils.FlattenImage(source)
+
+	for _, size := range models.ThumbnailSizes {
+		var buffer bytes.Buffer
+
+		err = jpeg.Encode(&buffer, utils.ResizeToFit(flat, size), &jpeg.Options{Quality: 85})
+
+		if err != nil {
+			return err
+		}
+
+		err = storage.Store.Put(attachment.ThumbnailKey(size), &buffer, int64(buffer.Len()), "image/jpeg")
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
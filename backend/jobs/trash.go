@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/storage"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+// ScheduleTrashPurge deletes for good what has been in the trash for longer than
+// TRASH_RETENTION_DAYS (default 30), it checks every TRASH_PURGE_INTERVAL_MINUTES (default 60)
+func ScheduleTrashPurge() {
+	retention := time.Duration(utils.GetEnvInt64("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour
+	interval := time.Duration(utils.GetEnvInt64("TRASH_PURGE_INTERVAL_MINUTES", 60)) * time.Minute
+
+	Schedule(Job{
+		Name:     "trash-purge",
+		Interval: interval,
+		Run: func(now time.Time) error {
+			return purgeTrash(now.Add(-retention).UTC())
+		},
+	})
+}
+
+func purgeTrash(deletedBefore time.Time) error {
+	tasks, err := models.GetDeletedTasks(deletedBefore)
+
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		err = purgeTask(task.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	categories, err := models.GetDeletedCategories(deletedBefore)
+
+	if err != nil {
+		return err
+	}
+
+	for _, category := range categories {
+		err = models.PurgeCategory(category.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	users, err := models.GetDeletedUsers(deletedBefore)
+
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		err = models.PurgeUser(user.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(tasks)+len(categories)+len(users) > 0 {
+		log.Printf("trash purge: deleted %d tasks, %d categories and %d users", len(tasks), len(categories), len(users))
+	}
+
+	return nil
+}
+
+// purgeTask deletes the task and then the files of its attachments
+func purgeTask(taskId int64) error {
+	attachments, err := models.GetTaskAttachments(taskId)
+
+	if err != nil {
+		return err
+	}
+
+	err = models.PurgeTask(taskId)
+
+	if err != nil {
+		return err
+	}
+
+	// the task is gone already, a file that can't be removed is only wasted space
+	for _, attachment := range attachments {
+		storage.Store.Delete(attachment.StorageKey)
+
+		if attachment.IsImage() {
+			for _, size := range models.ThumbnailSizes {
+				storage.Store.Delete(attachment.ThumbnailKey(size))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+const (
+	webhookPollInterval = 10 * time.Second
+	webhookBatchSize    = 50
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+var webhookWakeup = make(chan struct{}, 1)
+
+// WakeWebhookWorker makes the worker look at the queue right away instead of
+// waiting for the next poll
+func WakeWebhookWorker() {
+	select {
+	case webhookWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// StartWebhookWorker delivers the queued webhooks, failed deliveries are retried
+// with exponential backoff until WEBHOOK_MAX_ATTEMPTS (default 8) is reached
+func StartWebhookWorker() {
+	maxAttempts := int(utils.GetEnvInt64("WEBHOOK_MAX_ATTEMPTS", 8))
+
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+
+		for {
+			err := deliverDueWebhooks(time.Now(), maxAttempts)
+
+			if err != nil {
+				log.Printf("could not deliver webhooks: %v", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-webhookWakeup:
+			}
+		}
+	}()
+}
+
+// the webhooks with a goroutine sending their deliveries, the next passes leave them alone
+var (
+	sendingMutex sync.Mutex
+	sending      = make(map[int64]bool)
+)
+
+// deliverDueWebhooks sends the due deliveries of every webhook in a goroutine of its own and
+// doesn't wait for them, an endpoint that times out only holds up its own deliveries
+func deliverDueWebhooks(now time.Time, maxAttempts int) error {
+	sendingMutex.Lock()
+	busy := slices.Collect(maps.Keys(sending))
+	sendingMutex.Unlock()
+
+	deliveries, err := models.GetDueWebhookDeliveries(now, webhookBatchSize, busy)
+
+	if err != nil {
+		return err
+	}
+
+	// the deliveries of a webhook go out in order
+	queues := make(map[int64][]models.WebhookDelivery)
+
+	for _, delivery := range deliveries {
+		queues[delivery.WebhookID] = append(queues[delivery.WebhookID], delivery)
+	}
+
+	for webhookId, queue := range queues {
+		sendingMutex.Lock()
+		sending[webhookId] = true
+		sendingMutex.Unlock()
+
+		go func() {
+			err := deliverWebhookQueue(webhookId, queue, maxAttempts)
+
+			if err != nil {
+				log.Printf("could not deliver the webhooks of webhook %d: %v", webhookId, err)
+			}
+
+			sendingMutex.Lock()
+			delete(sending, webhookId)
+			sendingMutex.Unlock()
+
+			// what was queued for it meanwhile doesn't wait for the next poll
+			WakeWebhookWorker()
+		}()
+	}
+
+	return nil
+}
+
+func deliverWebhookQueue(webhookId int64, queue []models.WebhookDelivery, maxAttempts int) error {
+	webhook, err := models.GetWebhook(webhookId)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// a disabled webhook fails its queued deliveries right away, they can be redelivered once it is enabled again
+	enabled := webhook != nil && webhook.Active
+
+	for _, delivery := range queue {
+		if enabled {
+			delivery.Attempts++
+			delivery.ResponseCode, err = sendWebhook(*webhook, delivery, time.Now())
+		} else {
+			err = errors.New("webhook is disabled")
+		}
+
+		attemptedAt := time.Now().UTC()
+
+		switch {
+		case err == nil:
+			delivery.Status = models.DeliverySucceeded
+			delivery.LastError = ""
+			delivery.DeliveredAt = &attemptedAt
+		case !enabled || delivery.Attempts >= maxAttempts:
+			delivery.Status = models.DeliveryFailed
+			delivery.LastError = err.Error()
+		default:
+			delivery.LastError = err.Error()
+			delivery.NextAttemptAt = attemptedAt.Add(webhookBackoff(delivery.Attempts))
+		}
+
+		err = delivery.SaveAttempt()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// webhookBackoff doubles the wait after every failed attempt: 30s, 1m, 2m, 4m...
+// up to webhookMaxBackoff, doubling stops there so a big attempt count can't overflow
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, webhookMaxBackoff)
+}
+
+// sendWebhook posts the payload, anything but a 2xx response counts as a failure
+func sendWebhook(webhook models.Webhook, delivery models.WebhookDelivery, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "task-dashboard-webhooks")
+	request.Header.Set("X-Webhook-Event", delivery.EventType)
+	request.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	request.Header.Set("X-Webhook-Timestamp", timestamp)
+	request.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := webhookClient.Do(request)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+		return response.StatusCode, fmt.Errorf("endpoint responded with %d: %s", response.StatusCode, body)
+	}
+
+	return response.StatusCode, nil
+}
+
+// SignWebhook is the HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret,
+// the timestamp is part of the signature so a captured request can't be replayed later
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"topsecret", "1700000000", `{"a":1}`, "6a939b0c71853d606167625a15168ee9188c6a511c773ef4f42d307f3849e50f"},
+		{"other", "1700000000", `{"a":1}`, "2cb38bd50b3aa61b12df512da616c9577f2a99edb9467110d361a655e1ad3bd5"},
+		{"", "0", "", "b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, test := range tests {
+		if got := SignWebhook(test.secret, test.timestamp, []byte(test.body)); got != test.want {
+			t.Errorf("SignWebhook(%q, %q, %q) = %s, want %s", test.secret, test.timestamp, test.body, got, test.want)
+		}
+	}
+
+	// the timestamp is signed too, the same body sent later has another signature
+	if SignWebhook("topsecret", "1700000000", []byte("{}")) == SignWebhook("topsecret", "1700000001", []byte("{}")) {
+		t.Error("the signature does not change with the timestamp")
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{40, 6 * time.Hour},
+		{64, 6 * time.Hour},
+		{1000, 6 * time.Hour},
+	}
+
+	for _, test := range tests {
+		if got := webhookBackoff(test.attempts); got != test.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", test.attempts, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package listeners
+
+import (
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+)
+
+// the audit trail is written synchronously so it is there once the request returns
+func registerAudit() {
+	events.Subscribe("audit", func(event models.TaskCreated) error {
+		return recordAudit(event.Actor, "task", event.Task.ID, models.AuditCreate, nil, event.Task)
+	})
+
+	events.Subscribe("audit", func(event models.TaskUpdated) error {
+		return recordAudit(event.Actor, "task", event.After.ID, models.AuditUpdate, event.Before, event.After)
+	})
+
+	events.Subscribe("audit", func(event models.TaskDeleted) error {
+		return recordAudit(event.Actor, "task", event.Task.ID, models.AuditDelete, event.Task, nil)
+	})
+
+	events.Subscribe("audit", func(event models.TaskRestored) error {
+		return recordAudit(event.Actor, "task", event.Task.ID, models.AuditRestore, nil, event.Task)
+	})
+
+	events.Subscribe("audit", func(event models.CategoryCreated) error {
+		return recordAudit(event.Actor, "category", event.Category.ID, models.AuditCreate, nil, event.Category)
+	})
+
+	events.Subscribe("audit", func(event models.CategoryUpdated) error {
+		return recordAudit(event.Actor, "category", event.After.ID, models.AuditUpdate, event.Before, event.After)
+	})
+
+	events.Subscribe("audit", func(event models.CategoryDeleted) error {
+		return recordAudit(event.Actor, "category", event.Category.ID, models.AuditDelete, event.Category, nil)
+	})
+
+	events.Subscribe("audit", func(event models.CategoryRestored) error {
+		return recordAudit(event.Actor, "category", event.Category.ID, models.AuditRestore, nil, event.Category)
+	})
+
+	events.Subscribe("audit", func(event models.UserCreated) error {
+		return recordAudit(event.Actor, "user", event.User.ID, models.AuditCreate, nil, event.User)
+	})
+
+	events.Subscribe("audit", func(event models.UserDeleted) error {
+		return recordAudit(event.Actor, "user", event.User.ID, models.AuditDelete, event.User, nil)
+	})
+
+	events.Subscribe("audit", func(event models.UserRestored) error {
+		return recordAudit(event.Actor, "user", event.User.ID, models.AuditRestore, nil, event.User)
+	})
+}
+
+func recordAudit(actor models.Actor, entityType string, entityId int64, action models.AuditAction, before, after any) error {
+	event, err := models.NewAuditEvent(entityType, entityId, action, before, after)
+
+	if err != nil {
+		return err
+	}
+
+	event.ActorID = actor.UserID
+	event.RequestID = actor.RequestID
+
+	return event.Save()
+}
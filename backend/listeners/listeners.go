@@ -0,0 +1,10 @@
+package listeners
+
+// Register subscribes everything that reacts to the domain events,
+// it has to run before the server starts
+func Register() {
+	registerAudit()
+	registerNotifications()
+	registerWebhooks()
+	registerRealtime()
+}
@@ -0,0 +1,86 @@
+package listeners
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+)
+
+func registerNotifications() {
+	events.SubscribeWithID("notifications", func(eventId int64, event models.TaskCreated) error {
+		return notifyNewAssignees(eventId, event.Actor, event.Task, nil)
+	})
+
+	events.SubscribeWithID("notifications", func(eventId int64, event models.TaskUpdated) error {
+		return notifyNewAssignees(eventId, event.Actor, event.After, event.Before.AssigneesIDs)
+	})
+
+	events.SubscribeWithID("notifications", func(eventId int64, event models.CommentCreated) error {
+		return notifyComment(eventId, event.Comment)
+	})
+}
+
+// notifyNewAssignees notifies the users that were not assigned to the task before
+func notifyNewAssignees(eventId int64, actor models.Actor, task models.Task, previousAssignees []int64) error {
+	var newAssignees []int64
+
+	for _, userId := range task.AssigneesIDs {
+		if !slices.Contains(previousAssignees, userId) {
+			newAssignees = append(newAssignees, userId)
+		}
+	}
+
+	return models.Notify(newAssignees, models.Notification{
+		Type:    models.NotificationAssignment,
+		TaskID:  task.ID,
+		ActorID: actor.UserID,
+		Message: fmt.Sprintf("You were assigned to %q", task.Title),
+		EventID: eventId,
+	})
+}
+
+// notifyComment notifies the mentioned users and the other assignees of the task,
+// a mentioned assignee only gets the mention
+func notifyComment(eventId int64, comment models.Comment) error {
+	task, err := models.GetTask(comment.TaskID)
+
+	if err != nil {
+		return err
+	}
+
+	mentionedIds, err := models.GetUserIDsByUsernames(comment.MentionedUsernames())
+
+	if err != nil {
+		return err
+	}
+
+	err = models.Notify(mentionedIds, models.Notification{
+		Type:    models.NotificationMention,
+		TaskID:  task.ID,
+		ActorID: comment.UserID,
+		Message: fmt.Sprintf("You were mentioned in a comment on %q", task.Title),
+		EventID: eventId,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var assignees []int64
+
+	for _, userId := range task.AssigneesIDs {
+		if !slices.Contains(mentionedIds, userId) {
+			assignees = append(assignees, userId)
+		}
+	}
+
+	return models.Notify(assignees, models.Notification{
+		Type:    models.NotificationComment,
+		TaskID:  task.ID,
+		ActorID: comment.UserID,
+		Message: fmt.Sprintf("New comment on %q", task.Title),
+		EventID: eventId,
+	})
+}
@@ -0,0 +1,86 @@
+package listeners
+
+import (
+	"slices"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/realtime"
+)
+
+// pushing to the connected clients never has to hold up the request. Categories
+// go to everyone, the changes of a task only to the users involved in it
+func registerRealtime() {
+	events.SubscribeAsync("realtime", func(event models.TaskCreated) error {
+		return publishTaskEvent(event.Actor, models.EventTaskCreated, event.Task, event.Task.AssigneesIDs)
+	})
+
+	events.SubscribeAsync("realtime", func(event models.TaskUpdated) error {
+		return publishTaskEvent(event.Actor, models.EventTaskUpdated, event.After, event.Before.AssigneesIDs, event.After.AssigneesIDs)
+	})
+
+	events.SubscribeAsync("realtime", func(event models.TaskDeleted) error {
+		return publishTaskEvent(event.Actor, models.EventTaskDeleted, event.Task, event.Task.AssigneesIDs)
+	})
+
+	events.SubscribeAsync("realtime", func(event models.TaskRestored) error {
+		return publishTaskEvent(event.Actor, models.EventTaskRestored, event.Task, event.Task.AssigneesIDs)
+	})
+
+	events.SubscribeAsync("realtime", func(event models.CategoryCreated) error {
+		realtime.Publish(models.EventCategoryCreated, event.Category)
+		return nil
+	})
+
+	events.SubscribeAsync("realtime", func(event models.CategoryUpdated) error {
+		realtime.Publish(models.EventCategoryUpdated, event.After)
+		return nil
+	})
+
+	events.SubscribeAsync("realtime", func(event models.CategoryDeleted) error {
+		realtime.Publish(models.EventCategoryDeleted, event.Category)
+		return nil
+	})
+
+	events.SubscribeAsync("realtime", func(event models.CategoryRestored) error {
+		realtime.Publish(models.EventCategoryRestored, event.Category)
+		return nil
+	})
+
+	events.SubscribeAsync("realtime", func(event models.CommentCreated) error {
+		task, err := models.GetTask(event.Comment.TaskID)
+
+		if err != nil {
+			return err
+		}
+
+		return publishTaskEvent(event.Actor, models.EventCommentCreated, event.Comment, task.AssigneesIDs)
+	})
+}
+
+// publishTaskEvent sends the event to the assignees, to the ones the task had before
+// the change so they see it leave, to whoever made the change and to the admins
+func publishTaskEvent(actor models.Actor, eventType string, data any, assignees ...[]int64) error {
+	admins, err := models.GetAdminIDs()
+
+	if err != nil {
+		return err
+	}
+
+	// never nil, the broker sends nil to everyone
+	userIds := append([]int64{}, admins...)
+
+	for _, ids := range assignees {
+		userIds = append(userIds, ids...)
+	}
+
+	if actor.UserID != 0 {
+		userIds = append(userIds, actor.UserID)
+	}
+
+	slices.Sort(userIds)
+
+	realtime.PublishTo(slices.Compact(userIds), eventType, data)
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package listeners
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+	"github.com/abolfazlcodes/task-dashboard/backend/jobs"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+)
+
+// webhook deliveries are queued synchronously, the queue itself is persistent
+// and the worker does the slow part
+func registerWebhooks() {
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.TaskCreated) error {
+		return dispatchWebhook(eventId, models.EventTaskCreated, event.Task)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.TaskUpdated) error {
+		err := dispatchWebhook(eventId, models.EventTaskUpdated, event.After)
+
+		if err != nil || event.After.Status == event.Before.Status {
+			return err
+		}
+
+		return dispatchWebhook(eventId, models.EventTaskStatusChanged, map[string]any{
+			"task":            event.After,
+			"previous_status": event.Before.Status,
+		})
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.TaskDeleted) error {
+		return dispatchWebhook(eventId, models.EventTaskDeleted, event.Task)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.TaskRestored) error {
+		return dispatchWebhook(eventId, models.EventTaskRestored, event.Task)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.CategoryCreated) error {
+		return dispatchWebhook(eventId, models.EventCategoryCreated, event.Category)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.CategoryUpdated) error {
+		return dispatchWebhook(eventId, models.EventCategoryUpdated, event.After)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.CategoryDeleted) error {
+		return dispatchWebhook(eventId, models.EventCategoryDeleted, event.Category)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.CategoryRestored) error {
+		return dispatchWebhook(eventId, models.EventCategoryRestored, event.Category)
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.UserCreated) error {
+		return dispatchWebhook(eventId, models.EventUserCreated, webhookUser(event.User))
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.UserDeleted) error {
+		return dispatchWebhook(eventId, models.EventUserDeleted, webhookUser(event.User))
+	})
+
+	events.SubscribeWithID("webhooks", func(eventId int64, event models.UserRestored) error {
+		return dispatchWebhook(eventId, models.EventUserRestored, webhookUser(event.User))
+	})
+}
+
+// dispatchWebhook queues the event for every subscribed webhook. The event id comes from the
+// outbox event so a retried event is not queued twice and the receivers see the same id
+func dispatchWebhook(outboxId int64, eventType string, data any) error {
+	queued, err := models.EnqueueWebhookEvent(models.WebhookPayload{
+		EventID:   fmt.Sprintf("%d-%s", outboxId, eventType),
+		EventType: eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+
+	if queued > 0 {
+		jobs.WakeWebhookWorker()
+	}
+
+	return err
+}
+
+// webhookUser leaves the password out of user payloads
+func webhookUser(user models.User) map[string]any {
+	return map[string]any{
+		"id":         user.ID,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+		"username":   user.UserName,
+		"email":      user.Email,
+	}
+}
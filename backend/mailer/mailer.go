@@ -0,0 +1,78 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends a single email, implementations must be safe for concurrent use
+type Mailer interface {
+	Send(message Message) error
+}
+
+var Default Mailer
+
+func InitMailer() {
+	driver := os.Getenv("MAILER_DRIVER")
+
+	switch driver {
+	case "", "log":
+		Default = LogMailer{}
+	case "smtp":
+		port := os.Getenv("SMTP_PORT")
+
+		if port == "" {
+			port = "587"
+		}
+
+		Default = SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     fromAddress(),
+		}
+	case "file":
+		dir := os.Getenv("MAIL_FILE_DIR")
+
+		if dir == "" {
+			dir = "mails"
+		}
+
+		fileMailer, err := NewFileMailer(dir, fromAddress())
+
+		if err != nil {
+			panic(fmt.Sprintf("Could not initialize file mailer: %v", err))
+		}
+
+		Default = fileMailer
+	default:
+		panic(fmt.Sprintf("Unknown mailer driver %q", driver))
+	}
+}
+
+func fromAddress() string {
+	from := os.Getenv("MAIL_FROM")
+
+	if from == "" {
+		return "Task Dashboard <no-reply@localhost>"
+	}
+
+	return from
+}
+
+// LogMailer only writes the emails to the server log, handy for development
+type LogMailer struct{}
+
+func (LogMailer) Send(message Message) error {
+	log.Printf("email to %s: %s\n%s", message.To, message.Subject, message.Text)
+	return nil
+}
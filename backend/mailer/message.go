@@ -0,0 +1,79 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
+)
+
+// Bytes renders the message as a MIME email, with both the plain text and the
+// html body as multipart/alternative when the message has html
+func (message Message) Bytes(from string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+
+	if message.HTML == "" {
+		buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buffer.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		err := writeQuotedPrintable(&buffer, message.Text)
+
+		return buffer.Bytes(), err
+	}
+
+	writer := multipart.NewWriter(&buffer)
+
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	// the last part is the preferred one, so html goes after the plain text
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	}
+
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = writeQuotedPrintable(partWriter, part.body)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+
+	return buffer.Bytes(), err
+}
+
+func writeQuotedPrintable(destination io.Writer, body string) error {
+	writer := quotedprintable.NewWriter(destination)
+
+	_, err := writer.Write([]byte(body))
+
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send uses STARTTLS when the server offers it (net/smtp does that on its own)
+func (mailer SMTPMailer) Send(message Message) error {
+	body, err := message.Bytes(mailer.From)
+
+	if err != nil {
+		return err
+	}
+
+	// the envelope sender has to be the bare address, not "Name <address>"
+	sender, err := mail.ParseAddress(mailer.From)
+
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+
+	if mailer.Username != "" {
+		auth = smtp.PlainAuth("", mailer.Username, mailer.Password, mailer.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(mailer.Host, mailer.Port), auth, sender.Address, []string{message.To}, body)
+}
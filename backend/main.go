@@ -1,17 +1,38 @@
 package main
 
 import (
+	_ "time/tzdata" // user timezones must work even without tzdata on the host
+
 	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+	"github.com/abolfazlcodes/task-dashboard/backend/jobs"
+	"github.com/abolfazlcodes/task-dashboard/backend/listeners"
+	"github.com/abolfazlcodes/task-dashboard/backend/mailer"
+	"github.com/abolfazlcodes/task-dashboard/backend/middlewares"
+	"github.com/abolfazlcodes/task-dashboard/backend/realtime"
 	"github.com/abolfazlcodes/task-dashboard/backend/routes"
+	"github.com/abolfazlcodes/task-dashboard/backend/storage"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
 	db.InitDB()
+	storage.InitStorage()
+	mailer.InitMailer()
+	realtime.InitBroker()
+	jobs.StartThumbnailWorker()
+	jobs.ScheduleReminders()
+	jobs.ScheduleDigests()
+	jobs.ScheduleTrashPurge()
+	jobs.ScheduleAutoArchive()
+	jobs.ScheduleIdempotencyCleanup()
+	jobs.StartWebhookWorker()
+	listeners.Register()
+	events.StartRelay()
 
-	// create a http server
-	server := gin.Default()
-	// server.Use(gin.Logger())
+	// create a http server, gin.Default without its logger as that one would log stream tokens
+	server := gin.New()
+	server.Use(middlewares.Logger(), gin.Recovery())
 	routes.RegisterRoutes(server)
 
 	server.Run(":8080")
@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin has to run after Authenticate as it needs the userId
+func RequireAdmin(context *gin.Context) {
+	isAdmin, err := models.IsAdmin(context.GetInt64("userId"))
+
+	if err != nil || !isAdmin {
+		context.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"message": "Only admins can do this action.",
+		})
+		return
+	}
+
+	context.Next()
+}
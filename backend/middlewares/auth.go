@@ -43,3 +43,15 @@ func Authenticate(context *gin.Context) {
 
 	context.Next()
 }
+
+// AuthenticateStream is Authenticate for EventSource and WebSocket clients, browsers
+// can't set headers on those so the token may also be sent as ?access_token=
+func AuthenticateStream(context *gin.Context) {
+	accessToken := context.Query("access_token")
+
+	if context.GetHeader("Authorization") == "" && accessToken != "" {
+		context.Request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	Authenticate(context)
+}
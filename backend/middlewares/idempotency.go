@@ -0,0 +1,128 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// recordingWriter keeps a copy of the response body so it can be saved
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (writer *recordingWriter) Write(data []byte) (int, error) {
+	writer.body.Write(data)
+
+	return writer.ResponseWriter.Write(data)
+}
+
+func (writer *recordingWriter) WriteString(data string) (int, error) {
+	writer.body.WriteString(data)
+
+	return writer.ResponseWriter.WriteString(data)
+}
+
+// Idempotent replays the saved response when a request is retried with the same
+// Idempotency-Key, requests without the header are handled as usual. The keys are
+// per user and per route, on sign up nobody is logged in so they are per route only
+func Idempotent(context *gin.Context) {
+	key := context.GetHeader("Idempotency-Key")
+
+	if key == "" {
+		context.Next()
+		return
+	}
+
+	if len(key) > 255 {
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": "Idempotency-Key can be at most 255 characters.",
+		})
+		return
+	}
+
+	body, err := io.ReadAll(context.Request.Body)
+
+	if err != nil {
+		context.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"message": "Could not read the request body.",
+		})
+		return
+	}
+
+	context.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	scope := fmt.Sprintf("%d %s %s", context.GetInt64("userId"), context.Request.Method, context.FullPath())
+	hash := sha256.Sum256(body)
+	fingerprint := hex.EncodeToString(hash[:])
+
+	now := time.Now()
+
+	saved, err := models.ClaimIdempotencyKey(scope, key, fingerprint, now.Add(-models.IdempotencyWindow()), now.Add(-models.IdempotencyLease()))
+
+	if err != nil {
+		context.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the Idempotency-Key.",
+		})
+		return
+	}
+
+	if saved != nil {
+		replayIdempotentResponse(context, saved, fingerprint)
+		return
+	}
+
+	writer := &recordingWriter{ResponseWriter: context.Writer}
+	context.Writer = writer
+
+	completed := false
+
+	// server errors are not saved, the client should be able to retry them. A handler that
+	// panics never completes, the key is freed before the panic goes on to the recovery
+	defer func() {
+		var err error
+
+		if !completed || writer.Status() >= http.StatusInternalServerError {
+			err = models.ReleaseIdempotencyKey(scope, key)
+		} else {
+			err = models.SaveIdempotentResponse(scope, key, writer.Status(), writer.body.Bytes())
+		}
+
+		if err != nil {
+			log.Printf("could not save the response for Idempotency-Key %s: %v", key, err)
+		}
+	}()
+
+	context.Next()
+
+	completed = true
+}
+
+func replayIdempotentResponse(context *gin.Context, saved *models.IdempotentResponse, fingerprint string) {
+	if saved.Fingerprint != fingerprint {
+		context.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"message": "This Idempotency-Key was already used with another request body.",
+		})
+		return
+	}
+
+	if saved.StatusCode == 0 {
+		context.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"message": "A request with this Idempotency-Key is still running, try again later.",
+		})
+		return
+	}
+
+	context.Header("Idempotent-Replayed", "true")
+	context.Data(saved.StatusCode, "application/json; charset=utf-8", saved.Body)
+	context.Abort()
+}
@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// the keys are saved in a fresh database in a temporary directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "middlewares-test")
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.Chdir(dir)
+
+	if err != nil {
+		panic(err)
+	}
+
+	db.InitDB()
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+
+	db.DB.Close()
+	os.RemoveAll(dir)
+
+	os.Exit(code)
+}
+
+// idempotentServer counts the calls of the handler, it answers with the status it is told to
+// and panics on status 0
+func idempotentServer(calls *int, status *int) *gin.Engine {
+	server := gin.New()
+	server.Use(gin.Recovery())
+
+	server.POST("/things", Idempotent, func(context *gin.Context) {
+		*calls++
+
+		if *status == 0 {
+			panic("handler failed")
+		}
+
+		context.JSON(*status, gin.H{"call": *calls})
+	})
+
+	return server
+}
+
+func sendIdempotent(server *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+
+	if key != "" {
+		request.Header.Set("Idempotency-Key", key)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestIdempotent(t *testing.T) {
+	type step struct {
+		key        string
+		body       string
+		handler    int // the status the handler answers with, 0 makes it panic
+		wantStatus int
+		wantBody   string
+		wantCalls  int
+		replayed   bool
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "the retry is replayed",
+			steps: []step{
+				{"a", `{"x":1}`, http.StatusCreated, http.StatusCreated, `{"call":1}`, 1, false},
+				{"a", `{"x":1}`, http.StatusCreated, http.StatusCreated, `{"call":1}`, 1, true},
+			},
+		},
+		{
+			name: "client errors are replayed too",
+			steps: []step{
+				{"b", `{}`, http.StatusBadRequest, http.StatusBadRequest, `{"call":1}`, 1, false},
+				{"b", `{}`, http.StatusCreated, http.StatusBadRequest, `{"call":1}`, 1, true},
+			},
+		},
+		{
+			name: "another body with the same key",
+			steps: []step{
+				{"c", `{"x":1}`, http.StatusCreated, http.StatusCreated, `{"call":1}`, 1, false},
+				{"c", `{"x":2}`, http.StatusCreated, http.StatusUnprocessableEntity, "", 1, false},
+			},
+		},
+		{
+			name: "server errors can be retried",
+			steps: []step{
+				{"d", `{}`, http.StatusInternalServerError, http.StatusInternalServerError, `{"call":1}`, 1, false},
+				{"d", `{}`, http.StatusCreated, http.StatusCreated, `{"call":2}`, 2, false},
+			},
+		},
+		{
+			name: "a panic frees the key",
+			steps: []step{
+				{"e", `{}`, 0, http.StatusInternalServerError, "", 1, false},
+				{"e", `{}`, http.StatusCreated, http.StatusCreated, `{"call":2}`, 2, false},
+			},
+		},
+		{
+			name: "requests without a key run every time",
+			steps: []step{
+				{"", `{}`, http.StatusCreated, http.StatusCreated, `{"call":1}`, 1, false},
+				{"", `{}`, http.StatusCreated, http.StatusCreated, `{"call":2}`, 2, false},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		status := 0
+		server := idempotentServer(&calls, &status)
+
+		for i, step := range test.steps {
+			status = step.handler
+
+			recorder := sendIdempotent(server, step.key, step.body)
+
+			if recorder.Code != step.wantStatus {
+				t.Errorf("%s, step %d: status %d, want %d", test.name, i+1, recorder.Code, step.wantStatus)
+			}
+
+			if step.wantBody != "" && recorder.Body.String() != step.wantBody {
+				t.Errorf("%s, step %d: body %s, want %s", test.name, i+1, recorder.Body.String(), step.wantBody)
+			}
+
+			if calls != step.wantCalls {
+				t.Errorf("%s, step %d: the handler ran %d times, want %d", test.name, i+1, calls, step.wantCalls)
+			}
+
+			if replayed := recorder.Header().Get("Idempotent-Replayed") == "true"; replayed != step.replayed {
+				t.Errorf("%s, step %d: replayed %v, want %v", test.name, i+1, replayed, step.replayed)
+			}
+		}
+	}
+}
+
+func TestIdempotentRunningClaim(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	server := idempotentServer(&calls, &status)
+
+	scope := "0 POST /things"
+	fingerprint := "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a" // sha256 of {}
+
+	tests := []struct {
+		name       string
+		claimedAgo time.Duration
+		wantStatus int
+	}{
+		{"a running request keeps its key", time.Second, http.StatusConflict},
+		{"a claim past its lease was left by a crash", models.IdempotencyLease() + time.Minute, http.StatusCreated},
+	}
+
+	for i, test := range tests {
+		key := "running-" + string(rune('a'+i))
+
+		_, err := db.DB.Exec(`INSERT INTO idempotency_keys(scope, idempotency_key, fingerprint, created_at) VALUES(?, ?, ?, ?)`, scope, key, fingerprint, time.Now().UTC().Add(-test.claimedAgo))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := sendIdempotent(server, key, `{}`)
+
+		if recorder.Code != test.wantStatus {
+			t.Errorf("%s: status %d, want %d", test.name, recorder.Code, test.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logger is the gin access log without the ?access_token= of the stream routes,
+// a live token must never end up in the logs
+func Logger() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+		return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+			param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			param.StatusCode,
+			param.Latency,
+			param.ClientIP,
+			param.Method,
+			redactAccessToken(param.Path),
+			param.ErrorMessage,
+		)
+	})
+}
+
+func redactAccessToken(path string) string {
+	base, rawQuery, found := strings.Cut(path, "?")
+
+	if !found || !strings.Contains(rawQuery, "access_token") {
+		return path
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+
+	// a query we can't read might still hold the token
+	if err != nil {
+		return base + "?[unreadable query]"
+	}
+
+	if query.Has("access_token") {
+		query.Set("access_token", "REDACTED")
+	}
+
+	return base + "?" + query.Encode()
+}
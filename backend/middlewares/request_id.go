@@ -0,0 +1,21 @@
+package middlewares
+
+import (
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID keeps the X-Request-ID sent by a proxy or creates a new one,
+// so a request can be followed through the logs and the audit trail
+func RequestID(context *gin.Context) {
+	requestId := context.GetHeader("X-Request-ID")
+
+	if requestId == "" || len(requestId) > 64 {
+		requestId, _ = utils.GenerateRandomHex(16)
+	}
+
+	context.Set("requestId", requestId)
+	context.Header("X-Request-ID", requestId)
+
+	context.Next()
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+)
+
+// Archive takes the task off the lists and the board, it is still there for the
+// reports and can be fetched by id or listed with include_archived
+func (task Task) Archive(actor Actor) error {
+	now := time.Now().UTC()
+
+	return ArchiveTasks([]Task{task}, now, actor)
+}
+
+func (task Task) Unarchive(actor Actor) error {
+	query := `UPDATE tasks SET archived_at = NULL, version = version + 1 WHERE id = ? AND version = ?`
+
+	after := task
+	after.ArchivedAt = nil
+	after.Version++
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, task.ID, task.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkVersion(result)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []events.Event{TaskUpdated{Actor: actor, Before: task, After: after}}, nil
+	})
+}
+
+// ArchiveTasks archives the tasks in one transaction, an archive is a task update for the listeners
+func ArchiveTasks(tasks []Task, archivedAt time.Time, actor Actor) error {
+	query := `UPDATE tasks SET archived_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		var archivedEvents []events.Event
+
+		for _, task := range tasks {
+			result, err := tx.Exec(query, archivedAt, task.ID, task.Version)
+
+			if err != nil {
+				return nil, err
+			}
+
+			err = checkVersion(result)
+
+			if err != nil {
+				return nil, err
+			}
+
+			after := task
+			after.ArchivedAt = &archivedAt
+			after.Version++
+
+			archivedEvents = append(archivedEvents, TaskUpdated{Actor: actor, Before: task, After: after})
+		}
+
+		return archivedEvents, nil
+	})
+}
+
+// GetTasksDoneBefore returns the tasks that are not archived yet and were closed before
+// the time, tasks closed before the resolution time was tracked count from their last update.
+// Older rows keep the offset of the server, datetime() compares them in UTC
+func GetTasksDoneBefore(doneBefore time.Time) ([]Task, error) {
+	query := `SELECT id FROM tasks WHERE deleted_at IS NULL AND archived_at IS NULL AND status IN ` + closedStatuses + ` AND datetime(COALESCE(resolved_at, updated_at)) < datetime(?) ORDER BY id`
+
+	rows, err := db.DB.Query(query, doneBefore.UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var taskIds []int64
+
+	for rows.Next() {
+		var taskId int64
+
+		err := rows.Scan(&taskId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		taskIds = append(taskIds, taskId)
+	}
+
+	rows.Close()
+
+	var doneTasks []Task
+
+	for _, taskId := range taskIds {
+		task, err := GetTask(taskId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		doneTasks = append(doneTasks, *task)
+	}
+
+	return doneTasks, nil
+}
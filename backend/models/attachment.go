@@ -0,0 +1,150 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type Attachment struct {
+	ID          int64     `json:"id"`
+	TaskID      int64     `json:"task_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	UploadedBy  int64     `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	// set for images until their thumbnails are generated
+	ThumbnailsPending bool `json:"thumbnails_pending"`
+}
+
+// thumbnail box sizes in pixels, generated for every image attachment
+var ThumbnailSizes = []int{128, 320, 640}
+
+func (attachment Attachment) IsImage() bool {
+	return strings.HasPrefix(attachment.ContentType, "image/")
+}
+
+func (attachment Attachment) ThumbnailKey(size int) string {
+	return fmt.Sprintf("%s.thumb-%d.jpg", attachment.StorageKey, size)
+}
+
+func (attachment *Attachment) Save() error {
+	query := `INSERT INTO attachments(task_id, file_name, content_type, size, storage_key, uploaded_by, created_at, thumbnails_pending) VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+
+	stmt, err := db.DB.Prepare(query)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	attachment.ThumbnailsPending = attachment.IsImage()
+
+	result, err := stmt.Exec(attachment.TaskID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey, attachment.UploadedBy, attachment.CreatedAt, attachment.ThumbnailsPending)
+
+	if err != nil {
+		return err
+	}
+
+	attachment.ID, err = result.LastInsertId()
+
+	return err
+}
+
+func (attachment Attachment) Delete() error {
+	query := `DELETE FROM attachments WHERE id = ?`
+
+	stmt, err := db.DB.Prepare(query)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(attachment.ID)
+
+	return err
+}
+
+func GetAttachment(id int64) (*Attachment, error) {
+	query := `SELECT id, task_id, file_name, content_type, size, storage_key, COALESCE(uploaded_by, 0), created_at, thumbnails_pending FROM attachments WHERE id = ?`
+
+	row := db.DB.QueryRow(query, id)
+
+	var attachment Attachment
+
+	err := row.Scan(&attachment.ID, &attachment.TaskID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.UploadedBy, &attachment.CreatedAt, &attachment.ThumbnailsPending)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func GetTaskAttachments(taskId int64) ([]Attachment, error) {
+	query := `SELECT id, task_id, file_name, content_type, size, storage_key, COALESCE(uploaded_by, 0), created_at, thumbnails_pending FROM attachments WHERE task_id = ? ORDER BY id`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var attachments []Attachment
+
+	for rows.Next() {
+		var attachment Attachment
+
+		err := rows.Scan(&attachment.ID, &attachment.TaskID, &attachment.FileName, &attachment.ContentType, &attachment.Size, &attachment.StorageKey, &attachment.UploadedBy, &attachment.CreatedAt, &attachment.ThumbnailsPending)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// GetPendingThumbnails returns the ids of the images that still wait for their thumbnails
+func GetPendingThumbnails() ([]int64, error) {
+	rows, err := db.DB.Query(`SELECT id FROM attachments WHERE thumbnails_pending ORDER BY id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var attachmentIds []int64
+
+	for rows.Next() {
+		var attachmentId int64
+
+		err := rows.Scan(&attachmentId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attachmentIds = append(attachmentIds, attachmentId)
+	}
+
+	return attachmentIds, nil
+}
+
+func (attachment Attachment) ThumbnailsDone() error {
+	_, err := db.DB.Exec(`UPDATE attachments SET thumbnails_pending = 0 WHERE id = ?`, attachment.ID)
+
+	return err
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int64                  `json:"entity_id"`
+	Action     AuditAction            `json:"action"`
+	ActorID    int64                  `json:"actor_id"`
+	RequestID  string                 `json:"request_id"`
+	Changes    map[string]FieldChange `json:"changes"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type AuditFilter struct {
+	EntityType string
+	EntityID   int64
+	ActorID    int64
+	Action     AuditAction
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
+
+// fields that are never worth (or never safe) to keep in the audit trail
+var ignoredAuditFields = map[string]bool{
+	"ID":                 true,
+	"id":                 true,
+	"password":           true,
+	"updated_at":         true,
+	"checklist_progress": true,
+	"rank":               true, // only orders the board, the status change is what matters
+	"responded_at":       true, // follow the status changes
+	"resolved_at":        true,
+	"sla":                true, // worked out on every read
+	"deleted_at":         true, // the delete and restore actions tell it
+	"version":            true,
+}
+
+// NewAuditEvent diffs the json representation of before and after, pass nil as
+// before for a create and nil as after for a delete
+func NewAuditEvent(entityType string, entityId int64, action AuditAction, before, after any) (*AuditEvent, error) {
+	beforeFields, err := auditFields(before)
+
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := auditFields(after)
+
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]FieldChange)
+
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = FieldChange{Before: value, After: afterFields[field]}
+		}
+	}
+
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok && value != nil {
+			changes[field] = FieldChange{Before: nil, After: value}
+		}
+	}
+
+	return &AuditEvent{
+		EntityType: entityType,
+		EntityID:   entityId,
+		Action:     action,
+		Changes:    changes,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+func auditFields(value any) (map[string]any, error) {
+	fields := make(map[string]any)
+
+	if value == nil || reflect.ValueOf(value).IsZero() {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &fields)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for field := range fields {
+		if ignoredAuditFields[field] {
+			delete(fields, field)
+		}
+	}
+
+	return fields, nil
+}
+
+func (event *AuditEvent) Save() error {
+	query := `INSERT INTO audit_events(entity_type, entity_id, action, actor_id, request_id, changes, created_at) VALUES(?, ?, ?, ?, ?, ?, ?)`
+
+	changes, err := json.Marshal(event.Changes)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := db.DB.Exec(query, event.EntityType, event.EntityID, event.Action, event.ActorID, event.RequestID, string(changes), event.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	event.ID, err = result.LastInsertId()
+
+	return err
+}
+
+// GetAuditEvents returns the newest events first
+func GetAuditEvents(filter AuditFilter) ([]AuditEvent, error) {
+	query := `SELECT id, entity_type, entity_id, action, COALESCE(actor_id, 0), COALESCE(request_id, ''), changes, created_at FROM audit_events`
+
+	var conditions []string
+	var args []any
+
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+
+	if filter.EntityID != 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+
+	if filter.ActorID != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]AuditEvent, error) {
+	var events []AuditEvent
+
+	for rows.Next() {
+		var event AuditEvent
+		var changes string
+
+		err := rows.Scan(&event.ID, &event.EntityType, &event.EntityID, &event.Action, &event.ActorID, &event.RequestID, &changes, &event.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(changes), &event.Changes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,263 @@
+package models
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+var ErrBoardNeighbor = errors.New("the neighbour tasks have to be in the target column, in order")
+
+type BoardColumn struct {
+	Status WorkflowStatus `json:"status"`
+	Tasks  []Task         `json:"tasks"`
+}
+
+// BoardMove puts a task into a column between two tasks, AfterID is the task that ends up
+// right above it and BeforeID the one right below, with neither it goes to the bottom
+type BoardMove struct {
+	TaskID   int64  `json:"task_id" binding:"required"`
+	Status   Status `json:"status" binding:"required"`
+	AfterID  int64  `json:"after_id"`
+	BeforeID int64  `json:"before_id"`
+}
+
+// GetBoard groups the filtered tasks into the status columns in their manual order
+func GetBoard(filter TaskFilter) ([]BoardColumn, error) {
+	statuses, err := GetStatuses()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := GetTasks(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]BoardColumn, len(statuses))
+	columnIndex := make(map[Status]int)
+
+	for i, status := range statuses {
+		columns[i] = BoardColumn{Status: status, Tasks: []Task{}}
+		columnIndex[status.Name] = i
+	}
+
+	for _, task := range tasks {
+		i, ok := columnIndex[task.Status]
+
+		if !ok {
+			continue
+		}
+
+		columns[i].Tasks = append(columns[i].Tasks, task)
+	}
+
+	for _, column := range columns {
+		sortByRank(column.Tasks)
+	}
+
+	return columns, nil
+}
+
+// tasks from before the board have no rank yet, they sort first and by id
+func sortByRank(tasks []Task) {
+	slices.SortStableFunc(tasks, func(a, b Task) int {
+		if c := strings.Compare(a.Rank, b.Rank); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// MoveTask changes the status and the rank of the task in one transaction,
+// only the moved task gets a new rank
+func MoveTask(move BoardMove, actor Actor) (*Task, error) {
+	before, err := GetTask(move.TaskID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	after := *before
+	after.Status = move.Status
+	after.UpdatedAt = time.Now()
+
+	err = inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		err := rankUnrankedTasks(tx, move.Status, move.TaskID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lower, upper, err := moveBounds(tx, move)
+
+		if err != nil {
+			return nil, err
+		}
+
+		after.Rank, err = utils.RankBetween(lower, upper)
+
+		if err != nil {
+			return nil, ErrBoardNeighbor
+		}
+
+		err = after.trackSLAProgress(tx, before)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := tx.Exec(`UPDATE tasks SET status = ?, board_rank = ?, updated_at = ?, responded_at = ?, resolved_at = ?, version = version + 1 WHERE id = ? AND version = ?`, after.Status, after.Rank, after.UpdatedAt, after.RespondedAt, after.ResolvedAt, after.ID, before.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkVersion(result)
+
+		if err != nil {
+			return nil, err
+		}
+
+		after.Version++
+
+		return []events.Event{TaskUpdated{Actor: actor, Before: *before, After: after}}, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &after, nil
+}
+
+// moveBounds finds the ranks the moved task has to go between
+func moveBounds(tx *sql.Tx, move BoardMove) (string, string, error) {
+	var lower, upper string
+	var err error
+
+	if move.AfterID != 0 {
+		lower, err = neighborRank(tx, move, move.AfterID)
+
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if move.BeforeID != 0 {
+		upper, err = neighborRank(tx, move, move.BeforeID)
+
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	switch {
+	case move.AfterID != 0 && move.BeforeID == 0:
+		err = tx.QueryRow(`SELECT COALESCE(MIN(board_rank), '') FROM tasks WHERE status = ? AND board_rank > ? AND id != ?`, move.Status, lower, move.TaskID).Scan(&upper)
+	case move.AfterID == 0 && move.BeforeID != 0:
+		err = tx.QueryRow(`SELECT COALESCE(MAX(board_rank), '') FROM tasks WHERE status = ? AND board_rank < ? AND id != ?`, move.Status, upper, move.TaskID).Scan(&lower)
+	case move.AfterID == 0 && move.BeforeID == 0:
+		err = tx.QueryRow(`SELECT COALESCE(MAX(board_rank), '') FROM tasks WHERE status = ? AND id != ?`, move.Status, move.TaskID).Scan(&lower)
+	}
+
+	return lower, upper, err
+}
+
+func neighborRank(tx *sql.Tx, move BoardMove, neighborId int64) (string, error) {
+	if neighborId == move.TaskID {
+		return "", ErrBoardNeighbor
+	}
+
+	var rank string
+
+	err := tx.QueryRow(`SELECT board_rank FROM tasks WHERE id = ? AND status = ?`, neighborId, move.Status).Scan(&rank)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrBoardNeighbor
+	}
+
+	return rank, err
+}
+
+// bottomRank is a rank below every task of the column
+func bottomRank(tx *sql.Tx, status Status, excludeId int64) (string, error) {
+	var lastRank string
+
+	err := tx.QueryRow(`SELECT COALESCE(MAX(board_rank), '') FROM tasks WHERE status = ? AND id != ?`, status, excludeId).Scan(&lastRank)
+
+	if err != nil {
+		return "", err
+	}
+
+	return utils.RankBetween(lastRank, "")
+}
+
+// rankUnrankedTasks gives the tasks created before the board a rank, keeping
+// the order they are shown in. It only does something once per column. The moved
+// task is left out, it gets its rank from the move and its version must not change
+func rankUnrankedTasks(tx *sql.Tx, status Status, movedId int64) error {
+	rows, err := tx.Query(`SELECT id FROM tasks WHERE status = ? AND board_rank = '' AND id != ? ORDER BY id`, status, movedId)
+
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+
+		err = rows.Scan(&id)
+
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	rows.Close()
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var firstRank string
+
+	err = tx.QueryRow(`SELECT COALESCE(MIN(board_rank), '') FROM tasks WHERE status = ? AND board_rank != ''`, status).Scan(&firstRank)
+
+	if err != nil {
+		return err
+	}
+
+	previous := ""
+
+	for _, id := range ids {
+		rank, err := utils.RankBetween(previous, firstRank)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE tasks SET board_rank = ?, version = version + 1 WHERE id = ?`, rank, id)
+
+		if err != nil {
+			return err
+		}
+
+		previous = rank
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+)
+
+// the most tasks one bulk request can change
+const MaxBulkTasks = 500
+
+// TaskChange is a task before and after a change that was already checked
+type TaskChange struct {
+	Before Task
+	After  Task
+}
+
+// TaskConflictError is the ErrVersionConflict of a bulk change, it tells which task was changed
+type TaskConflictError struct {
+	TaskID int64
+}
+
+func (err TaskConflictError) Error() string {
+	return fmt.Sprintf("task %d: %v", err.TaskID, ErrVersionConflict)
+}
+
+func (err TaskConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+// CreateTasks saves the tasks in one transaction, either all of them are saved or none
+func CreateTasks(tasks []Task, actor Actor) error {
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		var createdEvents []events.Event
+
+		for i := range tasks {
+			err := tasks[i].insert(tx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			createdEvents = append(createdEvents, TaskCreated{Actor: actor, Task: tasks[i]})
+		}
+
+		return createdEvents, nil
+	})
+}
+
+// UpdateTasks writes every change in one transaction, either all of them are saved or none.
+// A task that was changed since it was read fails all of them with a TaskConflictError
+func UpdateTasks(changes []TaskChange, actor Actor) error {
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		var updatedEvents []events.Event
+
+		for _, change := range changes {
+			err := change.After.update(tx, change.Before)
+
+			if errors.Is(err, ErrVersionConflict) {
+				return nil, TaskConflictError{TaskID: change.After.ID}
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			updatedEvents = append(updatedEvents, TaskUpdated{Actor: actor, Before: change.Before, After: change.After})
+		}
+
+		return updatedEvents, nil
+	})
+}
+
+// DeleteTasks moves the tasks to the trash in one transaction, everything that
+// hangs off them is kept until they are purged. Like UpdateTasks it checks the versions
+func DeleteTasks(tasks []Task, actor Actor) error {
+	query := `UPDATE tasks SET deleted_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+
+	now := time.Now().UTC()
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		var deletedEvents []events.Event
+
+		for _, task := range tasks {
+			result, err := tx.Exec(query, now, task.ID, task.Version)
+
+			if err != nil {
+				return nil, err
+			}
+
+			err = checkVersion(result)
+
+			if errors.Is(err, ErrVersionConflict) {
+				return nil, TaskConflictError{TaskID: task.ID}
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			task.DeletedAt = &now
+			task.Version++
+
+			deletedEvents = append(deletedEvents, TaskDeleted{Actor: actor, Task: task})
+		}
+
+		return deletedEvents, nil
+	})
+}
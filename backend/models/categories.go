@@ -1,92 +1,207 @@
 package models
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
 )
 
 type Category struct {
 	ID          int64
-	Title       string `json:"title" binding:"required,min=3"`
-	Description string `json:"description"`
+	Title       string     `json:"title" binding:"required,min=3"`
+	Description string     `json:"description"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" binding:"-"`
+	Version     int64      `json:"version" binding:"-"` // bumped on every write, sent as the ETag
 }
 
-func (category Category) Save() error {
+func (category *Category) Save(actor Actor) error {
 	query := `INSERT INTO categories(title, description) VALUES(?, ?)`
 
-	stmt, err := db.DB.Prepare(query)
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, category.Title, category.Description)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return nil, err
+		}
 
-	defer stmt.Close()
+		category.ID, err = result.LastInsertId()
+		category.Version = 1
 
-	_, err = stmt.Exec(category.Title, category.Description)
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		return err
-	}
+		return []events.Event{CategoryCreated{Actor: actor, Category: *category}}, nil
+	})
+}
+
+// Delete moves the category to the trash, PurgeCategory deletes it for good. It fails
+// with ErrVersionConflict when the category is not at category.Version anymore
+func (category Category) Delete(actor Actor) error {
+	query := `UPDATE categories SET deleted_at = ?, version = version + 1 WHERE id = ? AND version = ?`
 
-	return nil
+	now := time.Now().UTC()
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, now, category.ID, category.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkVersion(result)
+
+		if err != nil {
+			return nil, err
+		}
+
+		category.DeletedAt = &now
+		category.Version++
+
+		return []events.Event{CategoryDeleted{Actor: actor, Category: category}}, nil
+	})
 }
 
-func (category Category) Delete() error {
-	query := `DELETE FROM categories WHERE id = ?`
+// Restore takes the category out of the trash
+func (category Category) Restore(actor Actor) error {
+	query := `UPDATE categories SET deleted_at = NULL, version = version + 1 WHERE id = ? AND version = ?`
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, category.ID, category.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkVersion(result)
+
+		if err != nil {
+			return nil, err
+		}
+
+		category.DeletedAt = nil
+		category.Version++
+
+		return []events.Event{CategoryRestored{Actor: actor, Category: category}}, nil
+	})
+}
 
-	stmt, err := db.DB.Prepare(query)
+// PurgeCategory deletes a category of the trash for good, its tasks lose the category
+// and its custom fields go with it
+func PurgeCategory(categoryId int64) error {
+	tx, err := db.DB.Begin()
 
 	if err != nil {
 		return err
 	}
 
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(category.ID)
+	queries := []string{
+		`UPDATE tasks SET category_id = NULL, version = version + 1 WHERE category_id = ?`,
+		`DELETE FROM task_custom_values WHERE field_id IN (SELECT id FROM custom_fields WHERE category_id = ?)`,
+		`DELETE FROM custom_fields WHERE category_id = ?`,
+		`DELETE FROM categories WHERE id = ?`,
+	}
 
-	if err != nil {
-		return err
+	for _, query := range queries {
+		_, err = tx.Exec(query, categoryId)
+
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (category Category) Update() error {
-	query := `UPDATE categories SET title = ?, description = ? WHERE id = ?`
+// Update writes the category over the one at category.Version, when someone else
+// changed it in between it fails with ErrVersionConflict
+func (category *Category) Update(actor Actor) error {
+	query := `UPDATE categories SET title = ?, description = ?, version = version + 1 WHERE id = ? AND version = ?`
 
-	stmt, err := db.DB.Prepare(query)
+	before, err := GetCategory(category.ID)
 
 	if err != nil {
 		return err
 	}
 
-	defer stmt.Close()
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, category.Title, category.Description, category.ID, category.Version)
 
-	_, err = stmt.Exec(category.Title, category.Description, category.ID)
+		if err != nil {
+			return nil, err
+		}
 
-	return err
-}
+		err = checkVersion(result)
 
-func GetCategory(id int64) (*Category, error) {
-	query := `SELECT * FROM categories WHERE id = ?`
+		if err != nil {
+			return nil, err
+		}
 
-	row := db.DB.QueryRow(query, id)
+		category.Version++
+
+		return []events.Event{CategoryUpdated{Actor: actor, Before: *before, After: *category}}, nil
+	})
+}
 
+const categoryColumns = `id, title, description, deleted_at, version`
+
+func scanCategory(row rowScanner) (*Category, error) {
 	var category Category
+	var deletedAt sql.NullTime
 
-	err := row.Scan(&category.ID, &category.Title, &category.Description)
+	err := row.Scan(&category.ID, &category.Title, &category.Description, &deletedAt, &category.Version)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if deletedAt.Valid {
+		category.DeletedAt = &deletedAt.Time
+	}
+
 	return &category, nil
+}
+
+func GetCategory(id int64) (*Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = ? AND deleted_at IS NULL`
+
+	return scanCategory(db.DB.QueryRow(query, id))
+}
+
+// GetCategoryByTitle ignores the case of the title
+func GetCategoryByTitle(title string) (*Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE title = ? COLLATE NOCASE AND deleted_at IS NULL`
 
+	return scanCategory(db.DB.QueryRow(query, title))
+}
+
+// GetDeletedCategory only finds categories in the trash
+func GetDeletedCategory(id int64) (*Category, error) {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = ? AND deleted_at IS NOT NULL`
+
+	return scanCategory(db.DB.QueryRow(query, id))
 }
 
 func GetAllCategories() ([]Category, error) {
-	query := "SELECT * FROM categories"
+	return getCategories(`deleted_at IS NULL`)
+}
 
-	rows, err := db.DB.Query(query)
+// GetDeletedCategories returns the trash, the latest deleted first. With a
+// non zero time only the ones deleted before it are returned
+func GetDeletedCategories(deletedBefore time.Time) ([]Category, error) {
+	if deletedBefore.IsZero() {
+		return getCategories(`deleted_at IS NOT NULL ORDER BY deleted_at DESC`)
+	}
+
+	return getCategories(`deleted_at < ? ORDER BY deleted_at DESC`, deletedBefore)
+}
+
+func getCategories(condition string, args ...any) ([]Category, error) {
+	rows, err := db.DB.Query(`SELECT `+categoryColumns+` FROM categories WHERE `+condition, args...)
 
 	if err != nil {
 		return nil, err
@@ -97,15 +212,13 @@ func GetAllCategories() ([]Category, error) {
 	var allCategories []Category
 
 	for rows.Next() {
-		var category Category
-
-		err := rows.Scan(&category.ID, &category.Title, &category.Description)
+		category, err := scanCategory(rows)
 
 		if err != nil {
 			return nil, err
 		}
 
-		allCategories = append(allCategories, category)
+		allCategories = append(allCategories, *category)
 	}
 
 	return allCategories, nil
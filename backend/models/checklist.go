@@ -0,0 +1,262 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type ChecklistItem struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	Text      string     `json:"text" binding:"required,min=1,max=250"`
+	Position  int        `json:"position"`
+	Checked   bool       `json:"checked"`
+	CheckedBy *int64     `json:"checked_by"`
+	CheckedAt *time.Time `json:"checked_at"`
+}
+
+type ChecklistProgress struct {
+	Checked int `json:"checked"`
+	Total   int `json:"total"`
+}
+
+var ErrChecklistOrderMismatch = errors.New("item_ids must contain every checklist item of the task exactly once")
+
+// Save appends the item at the end of the task checklist
+func (item *ChecklistItem) Save() error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `INSERT INTO checklist_items(task_id, text, position) VALUES(?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM checklist_items WHERE task_id = ?))`
+
+	result, err := tx.Exec(query, item.TaskID, item.Text, item.TaskID)
+
+	if err != nil {
+		return err
+	}
+
+	item.ID, err = result.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	err = bumpTaskVersion(tx, item.TaskID)
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return err
+	}
+
+	saved, err := GetChecklistItem(item.ID)
+
+	if err != nil {
+		return err
+	}
+
+	*item = *saved
+
+	return nil
+}
+
+// Toggle flips the checked state and remembers who checked it and when
+func (item *ChecklistItem) Toggle(userId int64) error {
+	item.Checked = !item.Checked
+
+	if item.Checked {
+		now := time.Now()
+		item.CheckedBy = &userId
+		item.CheckedAt = &now
+	} else {
+		item.CheckedBy = nil
+		item.CheckedAt = nil
+	}
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE checklist_items SET checked = ?, checked_by = ?, checked_at = ? WHERE id = ?`
+
+	_, err = tx.Exec(query, item.Checked, item.CheckedBy, item.CheckedAt, item.ID)
+
+	if err != nil {
+		return err
+	}
+
+	err = bumpTaskVersion(tx, item.TaskID)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (item ChecklistItem) Delete() error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM checklist_items WHERE id = ?`, item.ID)
+
+	if err != nil {
+		return err
+	}
+
+	err = bumpTaskVersion(tx, item.TaskID)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// the checklist is part of the task, so its edits change the version of the task too
+func bumpTaskVersion(tx *sql.Tx, taskId int64) error {
+	_, err := tx.Exec(`UPDATE tasks SET version = version + 1 WHERE id = ?`, taskId)
+
+	return err
+}
+
+func GetChecklistItem(id int64) (*ChecklistItem, error) {
+	query := `SELECT id, task_id, text, position, checked, checked_by, checked_at FROM checklist_items WHERE id = ?`
+
+	row := db.DB.QueryRow(query, id)
+
+	return scanChecklistItem(row)
+}
+
+func GetTaskChecklist(taskId int64) ([]ChecklistItem, error) {
+	query := `SELECT id, task_id, text, position, checked, checked_by, checked_at FROM checklist_items WHERE task_id = ? ORDER BY position, id`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var checklist []ChecklistItem
+
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		checklist = append(checklist, *item)
+	}
+
+	return checklist, nil
+}
+
+// ReorderChecklist rewrites the positions in the given order, the list has to
+// contain all of the task's items so nothing ends up with a duplicate position
+func ReorderChecklist(taskId int64, itemIDs []int64) error {
+	checklist, err := GetTaskChecklist(taskId)
+
+	if err != nil {
+		return err
+	}
+
+	if len(itemIDs) != len(checklist) || len(uniqueInt64s(itemIDs)) != len(itemIDs) {
+		return ErrChecklistOrderMismatch
+	}
+
+	belongsToTask := make(map[int64]bool)
+
+	for _, item := range checklist {
+		belongsToTask[item.ID] = true
+	}
+
+	for _, id := range itemIDs {
+		if !belongsToTask[id] {
+			return ErrChecklistOrderMismatch
+		}
+	}
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for index, id := range itemIDs {
+		_, err = tx.Exec(`UPDATE checklist_items SET position = ? WHERE id = ?`, index+1, id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = bumpTaskVersion(tx, taskId)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func getChecklistProgress(taskId int64) (*ChecklistProgress, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(checked), 0) FROM checklist_items WHERE task_id = ?`
+
+	var progress ChecklistProgress
+
+	err := db.DB.QueryRow(query, taskId).Scan(&progress.Total, &progress.Checked)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &progress, nil
+}
+
+func scanChecklistItem(row rowScanner) (*ChecklistItem, error) {
+	var item ChecklistItem
+	var checkedBy sql.NullInt64
+	var checkedAt sql.NullTime
+
+	err := row.Scan(&item.ID, &item.TaskID, &item.Text, &item.Position, &item.Checked, &checkedBy, &checkedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if checkedBy.Valid {
+		item.CheckedBy = &checkedBy.Int64
+	}
+
+	if checkedAt.Valid {
+		item.CheckedAt = &checkedAt.Time
+	}
+
+	return &item, nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+)
+
+type Comment struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	UserID    int64     `json:"user_id"`
+	Body      string    `json:"body" binding:"required,min=1,max=2000"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9._+-]+)`)
+
+func (comment *Comment) Save(actor Actor) error {
+	query := `INSERT INTO comments(task_id, user_id, body, created_at) VALUES(?, ?, ?, ?)`
+
+	comment.CreatedAt = time.Now().UTC()
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, comment.TaskID, comment.UserID, comment.Body, comment.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		comment.ID, err = result.LastInsertId()
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the first comment counts as the response to the task
+		_, err = tx.Exec(`UPDATE tasks SET responded_at = ?, version = version + 1 WHERE id = ? AND responded_at IS NULL`, comment.CreatedAt, comment.TaskID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []events.Event{CommentCreated{Actor: actor, Comment: *comment}}, nil
+	})
+}
+
+// MentionedUsernames returns the @usernames written in the comment body
+func (comment Comment) MentionedUsernames() []string {
+	var usernames []string
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(comment.Body, -1) {
+		// "@ali." at the end of a sentence mentions ali
+		usernames = append(usernames, strings.TrimRight(match[1], "."))
+	}
+
+	return usernames
+}
+
+func GetTaskComments(taskId int64) ([]Comment, error) {
+	query := `SELECT id, task_id, COALESCE(user_id, 0), body, created_at FROM comments WHERE task_id = ? ORDER BY id`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var comments []Comment
+
+	for rows.Next() {
+		var comment Comment
+
+		err := rows.Scan(&comment.ID, &comment.TaskID, &comment.UserID, &comment.Body, &comment.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
@@ -0,0 +1,494 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type CustomFieldType string
+
+const (
+	CustomFieldText         CustomFieldType = "text"
+	CustomFieldNumber       CustomFieldType = "number"
+	CustomFieldDate         CustomFieldType = "date"
+	CustomFieldSingleSelect CustomFieldType = "single_select"
+	CustomFieldMultiSelect  CustomFieldType = "multi_select"
+	CustomFieldUser         CustomFieldType = "user"
+	CustomFieldURL          CustomFieldType = "url"
+)
+
+// dates of date fields are plain days, stored like this they sort as text
+const customFieldDateLayout = "2006-01-02"
+
+const customFieldTextMaxLength = 1000
+
+// keys are used in query strings (?cf[story_points]=3) so they stay simple
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// CustomField is an admin defined field of the tasks, tasks keep their values by the field key
+type CustomField struct {
+	ID   int64           `json:"id"`
+	Key  string          `json:"key" binding:"required,max=40"`
+	Name string          `json:"name" binding:"required,max=80"`
+	Type CustomFieldType `json:"type" binding:"required,oneof=text number date single_select multi_select user url"`
+	// the choices of the select fields
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+	Position int      `json:"position" binding:"min=0"`
+	// 0 gives the field to every task, otherwise only the tasks of this category have it
+	CategoryID int64 `json:"category_id" binding:"min=0"`
+}
+
+// CustomFieldFilter keeps the tasks whose field has the value, or contains it for multi selects
+type CustomFieldFilter struct {
+	Field CustomField
+	Value any
+}
+
+func (field CustomField) IsSelect() bool {
+	return field.Type == CustomFieldSingleSelect || field.Type == CustomFieldMultiSelect
+}
+
+// ValidKey tells if the key can be used in query strings
+func (field CustomField) ValidKey() bool {
+	return customFieldKeyPattern.MatchString(field.Key)
+}
+
+// AppliesTo tells if tasks of the category have this field
+func (field CustomField) AppliesTo(categoryId int64) bool {
+	return field.CategoryID == 0 || field.CategoryID == categoryId
+}
+
+// Save adds the field, without a position it goes after the last one
+func (field *CustomField) Save() error {
+	if field.Position == 0 {
+		err := db.DB.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM custom_fields`).Scan(&field.Position)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	field.Options = field.optionsOrEmpty()
+
+	options, err := json.Marshal(field.Options)
+
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO custom_fields(field_key, name, type, options, required, position, category_id) VALUES(?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := db.DB.Exec(query, field.Key, field.Name, field.Type, string(options), field.Required, field.Position, nullableID(field.CategoryID))
+
+	if err != nil {
+		return err
+	}
+
+	field.ID, err = result.LastInsertId()
+
+	return err
+}
+
+// Update can't change the key or the type, the stored values depend on them
+func (field CustomField) Update() error {
+	options, err := json.Marshal(field.optionsOrEmpty())
+
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE custom_fields SET name = ?, options = ?, required = ?, position = ?, category_id = ? WHERE id = ?`
+
+	_, err = db.DB.Exec(query, field.Name, string(options), field.Required, field.Position, nullableID(field.CategoryID), field.ID)
+
+	return err
+}
+
+// Delete removes the field together with its values on every task
+func (field CustomField) Delete() error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE tasks SET version = version + 1 WHERE id IN (SELECT task_id FROM task_custom_values WHERE field_id = ?)`, field.ID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM task_custom_values WHERE field_id = ?`, field.ID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM custom_fields WHERE id = ?`, field.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (field CustomField) optionsOrEmpty() []string {
+	if field.Options == nil {
+		return []string{}
+	}
+
+	return field.Options
+}
+
+// GetCustomFields returns every field in display order
+func GetCustomFields() ([]CustomField, error) {
+	rows, err := db.DB.Query(`SELECT id, field_key, name, type, options, required, position, COALESCE(category_id, 0) FROM custom_fields ORDER BY position, id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var fields []CustomField
+
+	for rows.Next() {
+		var field CustomField
+		var options string
+
+		err = rows.Scan(&field.ID, &field.Key, &field.Name, &field.Type, &options, &field.Required, &field.Position, &field.CategoryID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(options), &field.Options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func GetCustomField(id int64) (*CustomField, error) {
+	fields, err := GetCustomFields()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if field.ID == id {
+			return &field, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// GetCustomFieldByKey returns sql.ErrNoRows for an unknown key
+func GetCustomFieldByKey(key string) (*CustomField, error) {
+	fields, err := GetCustomFields()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range fields {
+		if field.Key == key {
+			return &field, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// ValidateCustomValues checks the values sent for a task of the category against the field
+// definitions. It returns the values in the form they are stored in and the errors by field,
+// keyed like custom_fields.story_points. A null value clears the field
+func ValidateCustomValues(categoryId int64, values map[string]any) (map[string]any, map[string]string, error) {
+	fields, err := GetCustomFields()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanValues := make(map[string]any)
+	errorsOutput := make(map[string]string)
+
+	for key := range values {
+		index := slices.IndexFunc(fields, func(field CustomField) bool {
+			return field.Key == key && field.AppliesTo(categoryId)
+		})
+
+		if index == -1 {
+			errorsOutput["custom_fields."+key] = fmt.Sprintf("%s is not a custom field of this task", key)
+		}
+	}
+
+	for _, field := range fields {
+		if !field.AppliesTo(categoryId) {
+			continue
+		}
+
+		value, ok := values[field.Key]
+
+		if !ok || value == nil {
+			if field.Required {
+				errorsOutput["custom_fields."+field.Key] = fmt.Sprintf("%s is required", field.Key)
+			}
+
+			continue
+		}
+
+		cleanValue, err := field.cleanValue(value)
+
+		if err != nil {
+			errorsOutput["custom_fields."+field.Key] = err.Error()
+			continue
+		}
+
+		cleanValues[field.Key] = cleanValue
+	}
+
+	return cleanValues, errorsOutput, nil
+}
+
+// ApplicableCustomValues keeps the values whose fields tasks of the category have,
+// it is what is left of the values of a task that moves to another category
+func ApplicableCustomValues(categoryId int64, values map[string]any) (map[string]any, error) {
+	fields, err := GetCustomFields()
+
+	if err != nil {
+		return nil, err
+	}
+
+	applicable := make(map[string]any)
+
+	for _, field := range fields {
+		value, ok := values[field.Key]
+
+		if ok && field.AppliesTo(categoryId) {
+			applicable[field.Key] = value
+		}
+	}
+
+	return applicable, nil
+}
+
+// cleanValue checks a value decoded from json and turns it into the stored form
+func (field CustomField) cleanValue(value any) (any, error) {
+	invalid := fmt.Errorf("%s is not a valid %s", field.Key, field.Type)
+
+	switch field.Type {
+	case CustomFieldText:
+		text, ok := value.(string)
+
+		if !ok {
+			return nil, invalid
+		}
+
+		if len(text) > customFieldTextMaxLength {
+			return nil, fmt.Errorf("%s must be at most %d characters", field.Key, customFieldTextMaxLength)
+		}
+
+		return text, nil
+	case CustomFieldNumber:
+		number, ok := value.(float64)
+
+		if !ok {
+			return nil, invalid
+		}
+
+		return number, nil
+	case CustomFieldDate:
+		text, ok := value.(string)
+
+		if !ok {
+			return nil, invalid
+		}
+
+		date, err := time.Parse(customFieldDateLayout, text)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a date like 2024-01-31", field.Key)
+		}
+
+		return date.Format(customFieldDateLayout), nil
+	case CustomFieldSingleSelect:
+		option, ok := value.(string)
+
+		if !ok || !slices.Contains(field.Options, option) {
+			return nil, fmt.Errorf("%s must be one of the field options", field.Key)
+		}
+
+		return option, nil
+	case CustomFieldMultiSelect:
+		list, ok := value.([]any)
+
+		if !ok {
+			return nil, invalid
+		}
+
+		options := []string{}
+
+		for _, item := range list {
+			option, ok := item.(string)
+
+			if !ok || !slices.Contains(field.Options, option) {
+				return nil, fmt.Errorf("%s must only have the field options", field.Key)
+			}
+
+			if !slices.Contains(options, option) {
+				options = append(options, option)
+			}
+		}
+
+		return options, nil
+	case CustomFieldUser:
+		id, ok := value.(float64)
+
+		if !ok || id != math.Trunc(id) {
+			return nil, invalid
+		}
+
+		_, err := GetUser(int64(id))
+
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a user", field.Key)
+		}
+
+		return int64(id), nil
+	case CustomFieldURL:
+		text, ok := value.(string)
+
+		if !ok {
+			return nil, invalid
+		}
+
+		link, err := url.ParseRequestURI(text)
+
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return nil, fmt.Errorf("%s must be an http or https url", field.Key)
+		}
+
+		return text, nil
+	}
+
+	return nil, invalid
+}
+
+// ParseCustomFieldFilter reads a ?cf[key]=value filter of the task list
+func ParseCustomFieldFilter(key, value string) (*CustomFieldFilter, error) {
+	field, err := GetCustomFieldByKey(key)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("cf[%s] is not a custom field.", key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := CustomFieldFilter{Field: *field, Value: value}
+
+	switch field.Type {
+	case CustomFieldNumber:
+		filter.Value, err = strconv.ParseFloat(value, 64)
+	case CustomFieldUser:
+		filter.Value, err = strconv.ParseInt(value, 10, 64)
+	case CustomFieldDate:
+		_, err = time.Parse(customFieldDateLayout, value)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("cf[%s] could not be parsed.", key)
+	}
+
+	return &filter, nil
+}
+
+// condition returns the sql condition on the tasks table for the filter
+func (filter CustomFieldFilter) condition() (string, []any) {
+	if filter.Field.Type == CustomFieldMultiSelect {
+		return "id IN (SELECT v.task_id FROM task_custom_values v, json_each(v.value) item WHERE v.field_id = ? AND item.value = ?)", []any{filter.Field.ID, filter.Value}
+	}
+
+	return "id IN (SELECT task_id FROM task_custom_values WHERE field_id = ? AND json_extract(value, '$') = ?)", []any{filter.Field.ID, filter.Value}
+}
+
+func saveTaskCustomValues(tx *sql.Tx, task Task) error {
+	for key, value := range task.CustomFields {
+		if value == nil {
+			continue
+		}
+
+		encoded, err := json.Marshal(value)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO task_custom_values(task_id, field_id, value) SELECT ?, id, ? FROM custom_fields WHERE field_key = ?`, task.ID, string(encoded), key)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getTaskCustomValues(taskId int64) (map[string]any, error) {
+	query := `SELECT f.field_key, v.value FROM task_custom_values v JOIN custom_fields f ON f.id = v.field_id WHERE v.task_id = ?`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	values := make(map[string]any)
+
+	for rows.Next() {
+		var key, encoded string
+
+		err = rows.Scan(&key, &encoded)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var value any
+
+		err = json.Unmarshal([]byte(encoded), &value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
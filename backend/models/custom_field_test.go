@@ -0,0 +1,222 @@
+package models
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+// the tests run on a fresh database in a temporary directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "models-test")
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = os.Chdir(dir)
+
+	if err != nil {
+		panic(err)
+	}
+
+	db.InitDB()
+
+	code := m.Run()
+
+	db.DB.Close()
+	os.RemoveAll(dir)
+
+	os.Exit(code)
+}
+
+// addCustomFields creates the fields of the test and removes them once it is done
+func addCustomFields(t *testing.T, fields ...CustomField) {
+	for i := range fields {
+		err := fields[i].Save()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Cleanup(func() {
+		_, err := db.DB.Exec(`DELETE FROM custom_fields`)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestValidateCustomValues(t *testing.T) {
+	user := User{FirstName: "Ali", LastName: "Reza", Email: "custom-fields@example.com", Password: "secret1"}
+
+	err := user.Save(Actor{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	category := Category{Title: "Custom fields", Description: "test"}
+
+	err = category.Save(Actor{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addCustomFields(t,
+		CustomField{Key: "note", Name: "Note", Type: CustomFieldText},
+		CustomField{Key: "points", Name: "Points", Type: CustomFieldNumber, Required: true},
+		CustomField{Key: "launch", Name: "Launch", Type: CustomFieldDate},
+		CustomField{Key: "size", Name: "Size", Type: CustomFieldSingleSelect, Options: []string{"S", "M", "L"}},
+		CustomField{Key: "tags", Name: "Tags", Type: CustomFieldMultiSelect, Options: []string{"ui", "api"}},
+		CustomField{Key: "owner", Name: "Owner", Type: CustomFieldUser},
+		CustomField{Key: "link", Name: "Link", Type: CustomFieldURL},
+		CustomField{Key: "budget", Name: "Budget", Type: CustomFieldNumber, CategoryID: category.ID},
+	)
+
+	tests := []struct {
+		name       string
+		categoryId int64
+		values     map[string]any
+		want       map[string]any
+		errors     []string
+	}{
+		{
+			name:   "valid values in their stored form",
+			values: map[string]any{"note": "hi", "points": 3.5, "launch": "2024-01-31", "size": "M", "tags": []any{"ui", "api", "ui"}, "owner": float64(user.ID), "link": "https://example.com/a"},
+			want:   map[string]any{"note": "hi", "points": 3.5, "launch": "2024-01-31", "size": "M", "tags": []string{"ui", "api"}, "owner": user.ID, "link": "https://example.com/a"},
+		},
+		{
+			name:   "required field missing",
+			values: map[string]any{"note": "hi"},
+			errors: []string{"custom_fields.points"},
+		},
+		{
+			name:   "null clears an optional field but not a required one",
+			values: map[string]any{"points": nil, "note": nil},
+			errors: []string{"custom_fields.points"},
+		},
+		{
+			name:   "wrong types",
+			values: map[string]any{"points": "3", "note": float64(1), "tags": "ui", "owner": 1.5},
+			errors: []string{"custom_fields.points", "custom_fields.note", "custom_fields.tags", "custom_fields.owner"},
+		},
+		{
+			name:   "values outside the field rules",
+			values: map[string]any{"points": float64(1), "launch": "31/01/2024", "size": "XL", "tags": []any{"db"}, "owner": float64(999999), "link": "ftp://example.com"},
+			errors: []string{"custom_fields.launch", "custom_fields.size", "custom_fields.tags", "custom_fields.owner", "custom_fields.link"},
+		},
+		{
+			name:   "unknown key",
+			values: map[string]any{"points": float64(1), "nope": "x"},
+			errors: []string{"custom_fields.nope"},
+		},
+		{
+			name:   "field of another category",
+			values: map[string]any{"points": float64(1), "budget": float64(10)},
+			errors: []string{"custom_fields.budget"},
+		},
+		{
+			name:       "field of the task category",
+			categoryId: category.ID,
+			values:     map[string]any{"points": float64(1), "budget": float64(10)},
+			want:       map[string]any{"points": float64(1), "budget": float64(10)},
+		},
+	}
+
+	for _, test := range tests {
+		values, errorsOutput, err := ValidateCustomValues(test.categoryId, test.values)
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		var errorKeys []string
+
+		for key := range errorsOutput {
+			errorKeys = append(errorKeys, key)
+		}
+
+		slices.Sort(errorKeys)
+		slices.Sort(test.errors)
+
+		if !slices.Equal(errorKeys, test.errors) {
+			t.Errorf("%s: errors %v, want errors on %v", test.name, errorsOutput, test.errors)
+			continue
+		}
+
+		if test.want == nil {
+			continue
+		}
+
+		if len(values) != len(test.want) {
+			t.Errorf("%s: values %v, want %v", test.name, values, test.want)
+			continue
+		}
+
+		for key, want := range test.want {
+			got := values[key]
+
+			if wantList, ok := want.([]string); ok {
+				gotList, _ := got.([]string)
+
+				if !slices.Equal(gotList, wantList) {
+					t.Errorf("%s: %s = %v, want %v", test.name, key, got, want)
+				}
+			} else if got != want {
+				t.Errorf("%s: %s = %#v, want %#v", test.name, key, got, want)
+			}
+		}
+	}
+}
+
+func TestApplicableCustomValues(t *testing.T) {
+	category := Category{Title: "Applicable", Description: "test"}
+
+	err := category.Save(Actor{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addCustomFields(t,
+		CustomField{Key: "everywhere", Name: "Everywhere", Type: CustomFieldText},
+		CustomField{Key: "scoped", Name: "Scoped", Type: CustomFieldText, CategoryID: category.ID},
+	)
+
+	values := map[string]any{"everywhere": "a", "scoped": "b", "gone": "c"}
+
+	tests := []struct {
+		categoryId int64
+		want       []string
+	}{
+		{0, []string{"everywhere"}},
+		{category.ID, []string{"everywhere", "scoped"}},
+		{category.ID + 1, []string{"everywhere"}},
+	}
+
+	for _, test := range tests {
+		applicable, err := ApplicableCustomValues(test.categoryId, values)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var keys []string
+
+		for key := range applicable {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		if !slices.Equal(keys, test.want) {
+			t.Errorf("ApplicableCustomValues(%d) kept %v, want %v", test.categoryId, keys, test.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+type DigestSettings struct {
+	UserID     int64           `json:"user_id"`
+	Frequency  DigestFrequency `json:"frequency" binding:"required,oneof=off daily weekly"`
+	Timezone   string          `json:"timezone" binding:"required,timezone"`
+	LastSentAt *time.Time      `json:"last_sent_at"`
+}
+
+// DigestRecipient is a user that wants a digest together with their settings
+type DigestRecipient struct {
+	DigestSettings
+	Email     string
+	FirstName string
+}
+
+// GetDigestSettings returns the defaults (no digest, UTC) for users who never changed them
+func GetDigestSettings(userId int64) (*DigestSettings, error) {
+	query := `SELECT user_id, frequency, timezone, last_sent_at FROM digest_settings WHERE user_id = ?`
+
+	var settings DigestSettings
+	var lastSentAt sql.NullTime
+
+	err := db.DB.QueryRow(query, userId).Scan(&settings.UserID, &settings.Frequency, &settings.Timezone, &lastSentAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &DigestSettings{UserID: userId, Frequency: DigestOff, Timezone: "UTC"}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lastSentAt.Valid {
+		settings.LastSentAt = &lastSentAt.Time
+	}
+
+	return &settings, nil
+}
+
+func (settings DigestSettings) Save() error {
+	query := `INSERT INTO digest_settings(user_id, frequency, timezone) VALUES(?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET frequency = excluded.frequency, timezone = excluded.timezone`
+
+	_, err := db.DB.Exec(query, settings.UserID, settings.Frequency, settings.Timezone)
+
+	return err
+}
+
+func MarkDigestSent(userId int64, sentAt time.Time) error {
+	query := `UPDATE digest_settings SET last_sent_at = ? WHERE user_id = ?`
+
+	_, err := db.DB.Exec(query, sentAt.UTC(), userId)
+
+	return err
+}
+
+func GetDigestRecipients() ([]DigestRecipient, error) {
+	query := `
+		SELECT d.user_id, d.frequency, d.timezone, d.last_sent_at, u.email, u.first_name
+		FROM digest_settings d JOIN users u ON u.id = d.user_id
+		WHERE d.frequency != ? AND u.deleted_at IS NULL
+	`
+
+	rows, err := db.DB.Query(query, DigestOff)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var recipients []DigestRecipient
+
+	for rows.Next() {
+		var recipient DigestRecipient
+		var lastSentAt sql.NullTime
+
+		err := rows.Scan(&recipient.UserID, &recipient.Frequency, &recipient.Timezone, &lastSentAt, &recipient.Email, &recipient.FirstName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if lastSentAt.Valid {
+			recipient.LastSentAt = &lastSentAt.Time
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// GetOpenAssignedTasks returns the tasks assigned to the user that are not closed, soonest due first
+func GetOpenAssignedTasks(userId int64) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE deleted_at IS NULL AND archived_at IS NULL AND status NOT IN ` + closedStatuses + ` AND id IN (SELECT task_id FROM tasks_assignees WHERE user_id = ?) ORDER BY datetime(due_date)`
+
+	rows, err := db.DB.Query(query, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var tasks []Task
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, nil
+}
+
+// GetAssignedTasksActivity returns the audit events of the user's tasks since the given time,
+// the user's own changes are left out
+func GetAssignedTasksActivity(userId int64, since time.Time, limit int) ([]AuditEvent, error) {
+	query := `
+		SELECT e.id, e.entity_type, e.entity_id, e.action, COALESCE(e.actor_id, 0), COALESCE(e.request_id, ''), e.changes, e.created_at
+		FROM audit_events e
+		WHERE e.entity_type = 'task' AND e.created_at >= ? AND COALESCE(e.actor_id, 0) != ?
+		AND e.entity_id IN (SELECT task_id FROM tasks_assignees WHERE user_id = ?)
+		ORDER BY e.id DESC LIMIT ?
+	`
+
+	rows, err := db.DB.Query(query, since.UTC(), userId, userId, limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanAuditEvents(rows)
+}
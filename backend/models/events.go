@@ -0,0 +1,126 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+)
+
+// Actor is who made a change and in which request, every domain event carries it
+type Actor struct {
+	UserID    int64  `json:"user_id"`
+	RequestID string `json:"request_id"`
+}
+
+type TaskCreated struct {
+	Actor Actor `json:"actor"`
+	Task  Task  `json:"task"`
+}
+
+type TaskUpdated struct {
+	Actor  Actor `json:"actor"`
+	Before Task  `json:"before"`
+	After  Task  `json:"after"`
+}
+
+type TaskDeleted struct {
+	Actor Actor `json:"actor"`
+	Task  Task  `json:"task"`
+}
+
+type TaskRestored struct {
+	Actor Actor `json:"actor"`
+	Task  Task  `json:"task"`
+}
+
+type CategoryCreated struct {
+	Actor    Actor    `json:"actor"`
+	Category Category `json:"category"`
+}
+
+type CategoryUpdated struct {
+	Actor  Actor    `json:"actor"`
+	Before Category `json:"before"`
+	After  Category `json:"after"`
+}
+
+type CategoryDeleted struct {
+	Actor    Actor    `json:"actor"`
+	Category Category `json:"category"`
+}
+
+type CategoryRestored struct {
+	Actor    Actor    `json:"actor"`
+	Category Category `json:"category"`
+}
+
+// the user events never carry the password
+type UserCreated struct {
+	Actor Actor `json:"actor"`
+	User  User  `json:"user"`
+}
+
+type UserDeleted struct {
+	Actor Actor `json:"actor"`
+	User  User  `json:"user"`
+}
+
+type UserRestored struct {
+	Actor Actor `json:"actor"`
+	User  User  `json:"user"`
+}
+
+type CommentCreated struct {
+	Actor   Actor   `json:"actor"`
+	Comment Comment `json:"comment"`
+}
+
+func (TaskCreated) EventName() string      { return EventTaskCreated }
+func (TaskUpdated) EventName() string      { return EventTaskUpdated }
+func (TaskDeleted) EventName() string      { return EventTaskDeleted }
+func (TaskRestored) EventName() string     { return EventTaskRestored }
+func (CategoryCreated) EventName() string  { return EventCategoryCreated }
+func (CategoryUpdated) EventName() string  { return EventCategoryUpdated }
+func (CategoryDeleted) EventName() string  { return EventCategoryDeleted }
+func (CategoryRestored) EventName() string { return EventCategoryRestored }
+func (UserCreated) EventName() string      { return EventUserCreated }
+func (UserDeleted) EventName() string      { return EventUserDeleted }
+func (UserRestored) EventName() string     { return EventUserRestored }
+func (CommentCreated) EventName() string   { return EventCommentCreated }
+
+// inTransaction runs the change and records its events in one transaction,
+// the events are published once it is committed
+func inTransaction(change func(tx *sql.Tx) ([]events.Event, error)) error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	changeEvents, err := change(tx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, event := range changeEvents {
+		err = events.Record(tx, event)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+
+	if err != nil {
+		return err
+	}
+
+	events.Flush()
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package models
+
+import "github.com/abolfazlcodes/task-dashboard/backend/db"
+
+// EachTask hands the filtered tasks to handle in batches, in the order of GetTasks.
+// Only the ids of the whole list are held, so a big export never loads every task at once
+func EachTask(filter TaskFilter, batchSize int, handle func(tasks []Task) error) error {
+	query, args := filter.query("id")
+
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var taskIds []int64
+
+	for rows.Next() {
+		var taskId int64
+
+		err := rows.Scan(&taskId)
+
+		if err != nil {
+			return err
+		}
+
+		taskIds = append(taskIds, taskId)
+	}
+
+	rows.Close()
+
+	for start := 0; start < len(taskIds); start += batchSize {
+		tasks, err := getTasksByIDs(taskIds[start:min(start+batchSize, len(taskIds))])
+
+		if err != nil {
+			return err
+		}
+
+		err = completeTasks(tasks)
+
+		if err != nil {
+			return err
+		}
+
+		err = handle(filter.keep(tasks))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getTasksByIDs returns the tasks in the order of the ids, the ones that are gone or in the trash meanwhile are skipped
+func getTasksByIDs(taskIds []int64) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id IN (` + placeholders(len(taskIds)) + `) AND deleted_at IS NULL`
+
+	rows, err := db.DB.Query(query, int64sToArgs(taskIds)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tasksById := make(map[int64]Task)
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tasksById[task.ID] = *task
+	}
+
+	var tasks []Task
+
+	for _, taskId := range taskIds {
+		if task, ok := tasksById[taskId]; ok {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+// IdempotencyWindow is how long a saved response is replayed, IDEMPOTENCY_KEY_HOURS (default 24)
+func IdempotencyWindow() time.Duration {
+	return time.Duration(utils.GetEnvInt64("IDEMPOTENCY_KEY_HOURS", 24)) * time.Hour
+}
+
+// IdempotencyLease is how long a request keeps its key while it runs, IDEMPOTENCY_LEASE_SECONDS
+// (default 60). A key still running after that was left by a crash and can be claimed again
+func IdempotencyLease() time.Duration {
+	return time.Duration(utils.GetEnvInt64("IDEMPOTENCY_LEASE_SECONDS", 60)) * time.Second
+}
+
+// IdempotentResponse is what was answered to the first request with an Idempotency-Key,
+// StatusCode is 0 while that request is still running
+type IdempotentResponse struct {
+	Fingerprint string
+	StatusCode  int
+	Body        []byte
+}
+
+// ClaimIdempotencyKey saves the key for a new request and returns nil, when the key is
+// already taken it returns what was saved for it instead. Keys created before
+// expiredBefore are free again, and so are the ones still running that were claimed before abandonedBefore
+func ClaimIdempotencyKey(scope, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (*IdempotentResponse, error) {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	query := `DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? AND (created_at < ? OR (status_code = 0 AND created_at < ?))`
+
+	_, err = tx.Exec(query, scope, key, expiredBefore.UTC(), abandonedBefore.UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`INSERT OR IGNORE INTO idempotency_keys(scope, idempotency_key, fingerprint, created_at) VALUES(?, ?, ?, ?)`, scope, key, fingerprint, time.Now().UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	inserted, err := result.RowsAffected()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if inserted == 1 {
+		return nil, tx.Commit()
+	}
+
+	var saved IdempotentResponse
+
+	err = tx.QueryRow(`SELECT fingerprint, status_code, COALESCE(response_body, '') FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`, scope, key).Scan(&saved.Fingerprint, &saved.StatusCode, &saved.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &saved, tx.Commit()
+}
+
+func SaveIdempotentResponse(scope, key string, statusCode int, body []byte) error {
+	query := `UPDATE idempotency_keys SET status_code = ?, response_body = ? WHERE scope = ? AND idempotency_key = ?`
+
+	_, err := db.DB.Exec(query, statusCode, body, scope, key)
+
+	return err
+}
+
+// ReleaseIdempotencyKey frees the key of a request that failed, so it can be retried
+func ReleaseIdempotencyKey(scope, key string) error {
+	_, err := db.DB.Exec(`DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`, scope, key)
+
+	return err
+}
+
+func DeleteIdempotencyKeysBefore(before time.Time) error {
+	_, err := db.DB.Exec(`DELETE FROM idempotency_keys WHERE created_at < ?`, before.UTC())
+
+	return err
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+// GetJobLastRun returns the zero time for a job that never ran
+func GetJobLastRun(name string) (time.Time, error) {
+	var lastRun time.Time
+
+	err := db.DB.QueryRow(`SELECT last_run_at FROM job_runs WHERE name = ?`, name).Scan(&lastRun)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+
+	return lastRun, err
+}
+
+func SaveJobRun(name string, runAt time.Time) error {
+	query := `INSERT INTO job_runs(name, last_run_at) VALUES(?, ?)
+		ON CONFLICT(name) DO UPDATE SET last_run_at = excluded.last_run_at`
+
+	_, err := db.DB.Exec(query, name, runAt.UTC())
+
+	return err
+}
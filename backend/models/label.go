@@ -0,0 +1,139 @@
+package models
+
+import (
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type Label struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name" binding:"required,min=2"`
+	Color string `json:"color" binding:"required,hexcolor"`
+}
+
+func (label *Label) Save() error {
+	query := `INSERT INTO labels(name, color) VALUES(?, ?)`
+
+	stmt, err := db.DB.Prepare(query)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	result, err := stmt.Exec(label.Name, label.Color)
+
+	if err != nil {
+		return err
+	}
+
+	label.ID, err = result.LastInsertId()
+
+	return err
+}
+
+func (label Label) Update() error {
+	query := `UPDATE labels SET name = ?, color = ? WHERE id = ?`
+
+	stmt, err := db.DB.Prepare(query)
+
+	if err != nil {
+		return err
+	}
+
+	defer stmt.Close()
+
+	_, err = stmt.Exec(label.Name, label.Color, label.ID)
+
+	return err
+}
+
+// Delete removes the label from every task and then the label itself
+func (label Label) Delete() error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	queries := []string{
+		`UPDATE tasks SET version = version + 1 WHERE id IN (SELECT task_id FROM tasks_labels WHERE label_id = ?)`,
+		`DELETE FROM tasks_labels WHERE label_id = ?`,
+		`DELETE FROM labels WHERE id = ?`,
+	}
+
+	for _, query := range queries {
+		_, err = tx.Exec(query, label.ID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func GetLabel(id int64) (*Label, error) {
+	query := `SELECT id, name, color FROM labels WHERE id = ?`
+
+	row := db.DB.QueryRow(query, id)
+
+	var label Label
+
+	err := row.Scan(&label.ID, &label.Name, &label.Color)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+func GetAllLabels() ([]Label, error) {
+	query := `SELECT id, name, color FROM labels ORDER BY name`
+
+	rows, err := db.DB.Query(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var allLabels []Label
+
+	for rows.Next() {
+		var label Label
+
+		err := rows.Scan(&label.ID, &label.Name, &label.Color)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allLabels = append(allLabels, label)
+	}
+
+	return allLabels, nil
+}
+
+// LabelsExist is used to reject unknown label ids before attaching them to a task
+func LabelsExist(ids []int64) (bool, error) {
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	query := `SELECT COUNT(DISTINCT id) FROM labels WHERE id IN (` + placeholders(len(ids)) + `)`
+
+	var count int
+
+	err := db.DB.QueryRow(query, int64sToArgs(ids)...).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count == len(uniqueInt64s(ids)), nil
+}
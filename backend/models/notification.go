@@ -0,0 +1,233 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type NotificationType string
+
+const (
+	NotificationAssignment NotificationType = "assignment"
+	NotificationMention    NotificationType = "mention"
+	NotificationComment    NotificationType = "comment"
+	NotificationDueSoon    NotificationType = "due_soon"
+	NotificationOverdue    NotificationType = "overdue"
+)
+
+var NotificationTypes = []NotificationType{
+	NotificationAssignment,
+	NotificationMention,
+	NotificationComment,
+	NotificationDueSoon,
+	NotificationOverdue,
+}
+
+type Notification struct {
+	ID        int64            `json:"id"`
+	UserID    int64            `json:"user_id"`
+	Type      NotificationType `json:"type"`
+	TaskID    int64            `json:"task_id"`
+	ActorID   int64            `json:"actor_id"`
+	Message   string           `json:"message"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+	// the outbox event it was sent for, a retried event does not notify again
+	EventID int64 `json:"-"`
+}
+
+// Notify stores the notification for every user that did not turn this type off in one
+// transaction, the actor is skipped so nobody gets notified about their own actions.
+// With an EventID the users that already got it for that event are skipped too
+func Notify(userIds []int64, notification Notification) error {
+	notification.CreatedAt = time.Now().UTC()
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO notifications(user_id, type, task_id, actor_id, message, created_at, event_id) VALUES(?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(event_id, user_id, type) WHERE event_id IS NOT NULL DO NOTHING
+	`
+
+	for _, userId := range uniqueInt64s(userIds) {
+		if userId == notification.ActorID {
+			continue
+		}
+
+		enabled, err := isNotificationEnabled(userId, notification.Type)
+
+		if err != nil {
+			return err
+		}
+
+		if !enabled {
+			continue
+		}
+
+		_, err = tx.Exec(query, userId, notification.Type, nullableID(notification.TaskID), nullableID(notification.ActorID), notification.Message, notification.CreatedAt, nullableID(notification.EventID))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func GetNotifications(userId int64, unreadOnly bool, limit, offset int) ([]Notification, error) {
+	query := `SELECT id, user_id, type, COALESCE(task_id, 0), COALESCE(actor_id, 0), message, read_at, created_at FROM notifications WHERE user_id = ?`
+
+	if unreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+
+	query += ` ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	rows, err := db.DB.Query(query, userId, limit, offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var notifications []Notification
+
+	for rows.Next() {
+		var notification Notification
+		var readAt sql.NullTime
+
+		err := rows.Scan(&notification.ID, &notification.UserID, &notification.Type, &notification.TaskID, &notification.ActorID, &notification.Message, &readAt, &notification.CreatedAt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func CountUnreadNotifications(userId int64) (int, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`
+
+	var count int
+
+	err := db.DB.QueryRow(query, userId).Scan(&count)
+
+	return count, err
+}
+
+// MarkNotificationRead returns sql.ErrNoRows when the notification is not the user's
+func MarkNotificationRead(userId, notificationId int64) error {
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?`
+
+	result, err := db.DB.Exec(query, time.Now().UTC(), notificationId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func MarkAllNotificationsRead(userId int64) error {
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+
+	_, err := db.DB.Exec(query, time.Now().UTC(), userId)
+
+	return err
+}
+
+// GetNotificationPreferences returns every notification type with its state for the user
+func GetNotificationPreferences(userId int64) (map[NotificationType]bool, error) {
+	preferences := make(map[NotificationType]bool)
+
+	for _, notificationType := range NotificationTypes {
+		preferences[notificationType] = true
+	}
+
+	rows, err := db.DB.Query(`SELECT type, enabled FROM notification_preferences WHERE user_id = ?`, userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationType NotificationType
+		var enabled bool
+
+		err := rows.Scan(&notificationType, &enabled)
+
+		if err != nil {
+			return nil, err
+		}
+
+		preferences[notificationType] = enabled
+	}
+
+	return preferences, nil
+}
+
+func SaveNotificationPreferences(userId int64, preferences map[NotificationType]bool) error {
+	query := `INSERT INTO notification_preferences(user_id, type, enabled) VALUES(?, ?, ?)
+		ON CONFLICT(user_id, type) DO UPDATE SET enabled = excluded.enabled`
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	for notificationType, enabled := range preferences {
+		_, err = tx.Exec(query, userId, notificationType, enabled)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func isNotificationEnabled(userId int64, notificationType NotificationType) (bool, error) {
+	query := `SELECT enabled FROM notification_preferences WHERE user_id = ? AND type = ?`
+
+	var enabled bool
+
+	err := db.DB.QueryRow(query, userId, notificationType).Scan(&enabled)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+
+	return enabled, err
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+func TestNotifyOncePerEvent(t *testing.T) {
+	var userIds []int64
+
+	for _, email := range []string{"notify-a@example.com", "notify-b@example.com"} {
+		user := User{FirstName: "Notify", LastName: "Test", Email: email, Password: "secret1"}
+
+		err := user.Save(Actor{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		userIds = append(userIds, user.ID)
+	}
+
+	notification := Notification{Type: NotificationComment, Message: "New comment", EventID: 9001}
+
+	// the second call is the outbox handing the same event over again
+	for range 2 {
+		err := Notify(userIds, notification)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// without an event every call notifies
+	err := Notify(userIds[:1], Notification{Type: NotificationComment, Message: "Reminder"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userId int64
+		want   int
+	}{
+		{userIds[0], 2},
+		{userIds[1], 1},
+	}
+
+	for _, test := range tests {
+		var count int
+
+		err = db.DB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ?`, test.userId).Scan(&count)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count != test.want {
+			t.Errorf("user %d has %d notifications, want %d", test.userId, count, test.want)
+		}
+	}
+}
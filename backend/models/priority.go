@@ -0,0 +1,256 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+var ErrPriorityInUse = errors.New("tasks still have this priority, move them to another priority first")
+
+// PriorityLevel is one of the admin managed priorities, tasks refer to it by name
+type PriorityLevel struct {
+	ID       int64    `json:"id"`
+	Name     Priority `json:"name" binding:"required,max=40"`
+	Color    string   `json:"color" binding:"required,hexcolor"`
+	Position int      `json:"position" binding:"min=0"` // 1 is the most urgent
+	// sla targets counted from the creation of the task, 0 means no target
+	ResponseMinutes   int64 `json:"response_minutes" binding:"min=0"`
+	ResolutionMinutes int64 `json:"resolution_minutes" binding:"min=0"`
+}
+
+type SLAState string
+
+const (
+	SLAOnTrack  SLAState = "on_track"
+	SLAAtRisk   SLAState = "at_risk"
+	SLABreached SLAState = "breached"
+)
+
+// a target is at risk once this share of its time is used up
+const slaAtRiskShare = 0.8
+
+type SLA struct {
+	State           SLAState   `json:"state"`
+	ResponseDueAt   *time.Time `json:"response_due_at,omitempty"`
+	ResolutionDueAt *time.Time `json:"resolution_due_at,omitempty"`
+}
+
+// Save adds the priority, without a position it goes after the last one
+func (priority *PriorityLevel) Save() error {
+	if priority.Position == 0 {
+		err := db.DB.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM priorities`).Scan(&priority.Position)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO priorities(name, color, position, response_minutes, resolution_minutes) VALUES(?, ?, ?, ?, ?)`
+
+	result, err := db.DB.Exec(query, priority.Name, priority.Color, priority.Position, priority.ResponseMinutes, priority.ResolutionMinutes)
+
+	if err != nil {
+		return err
+	}
+
+	priority.ID, err = result.LastInsertId()
+
+	return err
+}
+
+// Update renames the priority on its tasks too
+func (priority PriorityLevel) Update() error {
+	existing, err := GetPriority(priority.ID)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE priorities SET name = ?, color = ?, position = ?, response_minutes = ?, resolution_minutes = ? WHERE id = ?`
+
+	_, err = tx.Exec(query, priority.Name, priority.Color, priority.Position, priority.ResponseMinutes, priority.ResolutionMinutes, priority.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if existing.Name != priority.Name {
+		_, err = tx.Exec(`UPDATE tasks SET priority = ?, version = version + 1 WHERE priority = ?`, priority.Name, existing.Name)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (priority PriorityLevel) Delete() error {
+	var taskCount int
+
+	err := db.DB.QueryRow(`SELECT COUNT(*) FROM tasks WHERE priority = ?`, priority.Name).Scan(&taskCount)
+
+	if err != nil {
+		return err
+	}
+
+	if taskCount > 0 {
+		return ErrPriorityInUse
+	}
+
+	_, err = db.DB.Exec(`DELETE FROM priorities WHERE id = ?`, priority.ID)
+
+	return err
+}
+
+// SLAFor works out the sla of the task, nil when the priority has no targets
+func (priority PriorityLevel) SLAFor(task Task, now time.Time) *SLA {
+	if priority.ResponseMinutes == 0 && priority.ResolutionMinutes == 0 {
+		return nil
+	}
+
+	sla := SLA{State: SLAOnTrack}
+
+	if priority.ResponseMinutes > 0 {
+		dueAt, state := slaTarget(task.CreatedAt, time.Duration(priority.ResponseMinutes)*time.Minute, task.RespondedAt, now)
+
+		sla.ResponseDueAt = &dueAt
+		sla.State = worseSLAState(sla.State, state)
+	}
+
+	if priority.ResolutionMinutes > 0 {
+		dueAt, state := slaTarget(task.CreatedAt, time.Duration(priority.ResolutionMinutes)*time.Minute, task.ResolvedAt, now)
+
+		sla.ResolutionDueAt = &dueAt
+		sla.State = worseSLAState(sla.State, state)
+	}
+
+	return &sla
+}
+
+func slaTarget(start time.Time, target time.Duration, metAt *time.Time, now time.Time) (time.Time, SLAState) {
+	dueAt := start.Add(target)
+
+	if metAt != nil {
+		if metAt.After(dueAt) {
+			return dueAt, SLABreached
+		}
+
+		return dueAt, SLAOnTrack
+	}
+
+	if now.After(dueAt) {
+		return dueAt, SLABreached
+	}
+
+	if now.After(start.Add(time.Duration(float64(target) * slaAtRiskShare))) {
+		return dueAt, SLAAtRisk
+	}
+
+	return dueAt, SLAOnTrack
+}
+
+func worseSLAState(a, b SLAState) SLAState {
+	if a == SLABreached || b == SLABreached {
+		return SLABreached
+	}
+
+	if a == SLAAtRisk || b == SLAAtRisk {
+		return SLAAtRisk
+	}
+
+	return SLAOnTrack
+}
+
+// GetPriorities returns the priorities, most urgent first
+func GetPriorities() ([]PriorityLevel, error) {
+	rows, err := db.DB.Query(`SELECT id, name, color, position, response_minutes, resolution_minutes FROM priorities ORDER BY position, id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var priorities []PriorityLevel
+
+	for rows.Next() {
+		var priority PriorityLevel
+
+		err = rows.Scan(&priority.ID, &priority.Name, &priority.Color, &priority.Position, &priority.ResponseMinutes, &priority.ResolutionMinutes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		priorities = append(priorities, priority)
+	}
+
+	return priorities, nil
+}
+
+func GetPriority(id int64) (*PriorityLevel, error) {
+	priorities, err := GetPriorities()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, priority := range priorities {
+		if priority.ID == id {
+			return &priority, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// GetPriorityByName returns sql.ErrNoRows for an unknown priority
+func GetPriorityByName(name Priority) (*PriorityLevel, error) {
+	priorities, err := GetPriorities()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, priority := range priorities {
+		if priority.Name == name {
+			return &priority, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// applySLA fills in the sla of the tasks, it reads the priorities once for the whole list
+func applySLA(tasks []Task) error {
+	priorities, err := GetPriorities()
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for i := range tasks {
+		for _, priority := range priorities {
+			if priority.Name == tasks[i].Priority {
+				tasks[i].SLA = priority.SLAFor(tasks[i], now)
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// ErrVersionConflict means the row was changed since it was read, versioned writes
+// only go through while the row still has the version they expect
+var ErrVersionConflict = errors.New("it was changed by someone else in the meantime")
+
+// checkVersion turns a versioned write that matched no row into ErrVersionConflict
+func checkVersion(result sql.Result) error {
+	updatedRows, err := result.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if updatedRows == 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// rowScanner is either *sql.Row or *sql.Rows, so one scan function can serve both
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// placeholders returns "?, ?, ?" for building IN (...) clauses
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+func int64sToArgs(values []int64) []any {
+	args := make([]any, len(values))
+
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return args
+}
+
+func uniqueInt64s(values []int64) []int64 {
+	seen := make(map[int64]bool)
+
+	var unique []int64
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
+
+// nullableID stores a missing (zero) id as NULL
+func nullableID(id int64) any {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
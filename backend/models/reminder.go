@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+// DueAssignment is an assignee of a task that is not done yet
+type DueAssignment struct {
+	TaskID    int64
+	Title     string
+	DueDate   time.Time
+	UserID    int64
+	Email     string
+	FirstName string
+}
+
+// GetAssignmentsDueBefore compares the due dates with datetime(), they are stored with the
+// offset the client sent and as text they would not compare in UTC
+func GetAssignmentsDueBefore(deadline time.Time) ([]DueAssignment, error) {
+	query := `
+		SELECT t.id, t.title, t.due_date, u.id, u.email, u.first_name
+		FROM tasks t
+		JOIN tasks_assignees a ON a.task_id = t.id
+		JOIN users u ON u.id = a.user_id
+		WHERE t.deleted_at IS NULL AND t.archived_at IS NULL AND u.deleted_at IS NULL AND t.status NOT IN ` + closedStatuses + ` AND datetime(t.due_date) <= datetime(?)
+		ORDER BY datetime(t.due_date)
+	`
+
+	rows, err := db.DB.Query(query, deadline.UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var assignments []DueAssignment
+
+	for rows.Next() {
+		var assignment DueAssignment
+
+		err := rows.Scan(&assignment.TaskID, &assignment.Title, &assignment.DueDate, &assignment.UserID, &assignment.Email, &assignment.FirstName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, nil
+}
+
+// ClaimReminder records the reminder and reports if it is new, a reminder is
+// claimed before it's sent so it fires at most once even across restarts
+func ClaimReminder(taskId, userId int64, kind NotificationType, dueDate time.Time) (bool, error) {
+	query := `INSERT OR IGNORE INTO task_reminders(task_id, user_id, kind, due_date, sent_at) VALUES(?, ?, ?, ?, ?)`
+
+	result, err := db.DB.Exec(query, taskId, userId, kind, dueDate.UTC(), time.Now().UTC())
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	return affected == 1, err
+}
@@ -0,0 +1,295 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type StatusCategory string
+
+const (
+	StatusCategoryOpen       StatusCategory = "open"
+	StatusCategoryInProgress StatusCategory = "in_progress"
+	StatusCategoryClosed     StatusCategory = "closed"
+)
+
+// closedStatuses is for queries that skip finished tasks
+const closedStatuses = `(SELECT name FROM statuses WHERE category = 'closed')`
+
+var ErrStatusInUse = errors.New("tasks still have this status, move them to another status first")
+
+// WorkflowStatus is a board column, tasks refer to it by name
+type WorkflowStatus struct {
+	ID       int64          `json:"id"`
+	Name     Status         `json:"name" binding:"required,max=40"`
+	Color    string         `json:"color" binding:"required,hexcolor"`
+	Position int            `json:"position" binding:"min=0"`
+	Category StatusCategory `json:"category" binding:"required,oneof=open in_progress closed"`
+	// the statuses a task can move to from this one, empty allows every status
+	Transitions []Status `json:"transitions"`
+}
+
+// Save adds the status, without a position it goes after the last one
+func (status *WorkflowStatus) Save() error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if status.Position == 0 {
+		err = tx.QueryRow(`SELECT COALESCE(MAX(position), 0) + 1 FROM statuses`).Scan(&status.Position)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO statuses(name, color, position, category) VALUES(?, ?, ?, ?)`, status.Name, status.Color, status.Position, status.Category)
+
+	if err != nil {
+		return err
+	}
+
+	status.ID, err = result.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	err = saveStatusTransitions(tx, *status)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update renames the status on its tasks too
+func (status WorkflowStatus) Update() error {
+	existing, err := GetStatus(status.ID)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE statuses SET name = ?, color = ?, position = ?, category = ? WHERE id = ?`, status.Name, status.Color, status.Position, status.Category, status.ID)
+
+	if err != nil {
+		return err
+	}
+
+	if existing.Name != status.Name {
+		_, err = tx.Exec(`UPDATE tasks SET status = ?, version = version + 1 WHERE status = ?`, status.Name, existing.Name)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM status_transitions WHERE from_status_id = ?`, status.ID)
+
+	if err != nil {
+		return err
+	}
+
+	err = saveStatusTransitions(tx, status)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (status WorkflowStatus) Delete() error {
+	var taskCount int
+
+	err := db.DB.QueryRow(`SELECT COUNT(*) FROM tasks WHERE status = ?`, status.Name).Scan(&taskCount)
+
+	if err != nil {
+		return err
+	}
+
+	if taskCount > 0 {
+		return ErrStatusInUse
+	}
+
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM status_transitions WHERE from_status_id = ? OR to_status_id = ?`, status.ID, status.ID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM statuses WHERE id = ?`, status.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CanMoveTo tells if a task with this status may move to the given one
+func (status WorkflowStatus) CanMoveTo(to Status) bool {
+	return status.Name == to || len(status.Transitions) == 0 || slices.Contains(status.Transitions, to)
+}
+
+func saveStatusTransitions(tx *sql.Tx, status WorkflowStatus) error {
+	for _, name := range status.Transitions {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO status_transitions(from_status_id, to_status_id) SELECT ?, id FROM statuses WHERE name = ?`, status.ID, name)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetStatuses returns the statuses in board order
+func GetStatuses() ([]WorkflowStatus, error) {
+	rows, err := db.DB.Query(`SELECT id, name, color, position, category FROM statuses ORDER BY position, id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var statuses []WorkflowStatus
+
+	for rows.Next() {
+		var status WorkflowStatus
+
+		err = rows.Scan(&status.ID, &status.Name, &status.Color, &status.Position, &status.Category)
+
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	rows.Close()
+
+	transitions, err := getStatusTransitions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range statuses {
+		statuses[i].Transitions = transitions[statuses[i].ID]
+	}
+
+	return statuses, nil
+}
+
+func GetStatus(id int64) (*WorkflowStatus, error) {
+	statuses, err := GetStatuses()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		if status.ID == id {
+			return &status, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// GetStatusByName returns sql.ErrNoRows for an unknown status
+func GetStatusByName(name Status) (*WorkflowStatus, error) {
+	statuses, err := GetStatuses()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		if status.Name == name {
+			return &status, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// GetStartStatus returns the first open status of the board, new tasks from templates start there
+func GetStartStatus() (*WorkflowStatus, error) {
+	statuses, err := GetStatuses()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		if status.Category == StatusCategoryOpen {
+			return &status, nil
+		}
+	}
+
+	if len(statuses) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return &statuses[0], nil
+}
+
+func getStatusTransitions() (map[int64][]Status, error) {
+	rows, err := db.DB.Query(`
+		SELECT st.from_status_id, s.name
+		FROM status_transitions st
+		JOIN statuses s ON s.id = st.to_status_id
+		ORDER BY s.position, s.id
+	`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	transitions := make(map[int64][]Status)
+
+	for rows.Next() {
+		var fromId int64
+		var to Status
+
+		err = rows.Scan(&fromId, &to)
+
+		if err != nil {
+			return nil, err
+		}
+
+		transitions[fromId] = append(transitions[fromId], to)
+	}
+
+	return transitions, nil
+}
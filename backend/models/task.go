@@ -1,86 +1,661 @@
 package models
 
 import (
+	"database/sql"
+	"strings"
 	"time"
 
 	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
 )
 
 // A task has these data:
 // title / description / priority / status / due_date ? created_at / updated_at / assignees / category /
-type Priority string
 
-const (
-	PriorityLow    Priority = "low"
-	PriorityMedium Priority = "medium"
-	PriorityHigh   Priority = "high"
-)
+// Priority is the name of one of the priority levels, see PriorityLevel
+type Priority string
 
+// Status is the name of one of the workflow statuses, see WorkflowStatus
 type Status string
 
-const (
-	StatusDone       Status = "done"
-	StatusInProgress Status = "in-progress"
-	StatusTodo       Status = "todo"
-)
-
 type Task struct {
-	ID           int64
-	Title        string    `json:"title" binding:"required,min=3"`
-	Description  string    `json:"description"`
-	Priority     Priority  `json:"priority" binding:"required"`
-	Status       Status    `json:"status" binding:"required"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	DueDate      time.Time `json:"due_date" binding:"required"`
-	CategoryID   int64     `json:"category_id"`
-	AssigneesIDs []int64   `json:"assignees_ids" binding:"required"` // better to tell AssigneesIDs as we only get ids
-}
-
-func (task Task) Save() error {
-	query := `INSERT INTO tasks(title, description, priority, status, created_at, updated_at, due_date, category_id) VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+	ID              int64
+	Title           string    `json:"title" binding:"required,min=3"`
+	Description     string    `json:"description"`
+	Priority        Priority  `json:"priority" binding:"required"`
+	Status          Status    `json:"status" binding:"required"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	DueDate         time.Time `json:"due_date" binding:"required"`
+	CategoryID      int64     `json:"category_id"`
+	EstimateMinutes int64     `json:"estimate_minutes" binding:"min=0"`
+	AssigneesIDs    []int64   `json:"assignees_ids" binding:"required"` // better to tell AssigneesIDs as we only get ids
+	LabelIDs        []int64   `json:"label_ids"`
+	Rank            string    `json:"rank" binding:"-"` // position within the board column, set by the server
+	// set by the server when the task first leaves an open status and when it is closed
+	RespondedAt *time.Time `json:"responded_at" binding:"-"`
+	ResolvedAt  *time.Time `json:"resolved_at" binding:"-"`
+	ParentID    int64      `json:"parent_id" binding:"-"` // set on the subtasks of a template
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" binding:"-"`
+	ArchivedAt  *time.Time `json:"archived_at" binding:"-"` // archived tasks are left out of the lists by default
+	Version     int64      `json:"version" binding:"-"`     // bumped on every write, sent as the ETag
+	// values of the custom fields by field key, see CustomField
+	CustomFields map[string]any `json:"custom_fields"`
+
+	ChecklistProgress *ChecklistProgress `json:"checklist_progress,omitempty" binding:"-"`
+	SLA               *SLA               `json:"sla,omitempty" binding:"-"`
+}
+
+// TaskFilter holds the optional filters of the task list, zero values are ignored
+type TaskFilter struct {
+	Status     Status
+	Priority   Priority
+	CategoryID int64
+	ParentID   int64
+	LabelsAny  []int64 // tasks having at least one of these labels
+	LabelsAll  []int64 // tasks having every one of these labels
+	LabelsNone []int64 // tasks having none of these labels
+	// only tasks that missed one of their sla targets, worked out after the query
+	SLABreached  bool
+	CustomFields []CustomFieldFilter
+	// sorts by this custom field instead of the due date, tasks without a value come last
+	SortField *CustomField
+	SortDesc  bool
+	// archived tasks are only listed when this is set
+	IncludeArchived bool
+}
+
+func (task *Task) Save(actor Actor) error {
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		err := task.insert(tx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []events.Event{TaskCreated{Actor: actor, Task: *task}}, nil
+	})
+}
+
+// insert writes a new task with its relations, the caller records the event
+func (task *Task) insert(tx *sql.Tx) error {
+	query := `INSERT INTO tasks(title, description, priority, status, created_at, updated_at, due_date, category_id, estimate_minutes, board_rank, responded_at, resolved_at, parent_id) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	stmt, err := db.DB.Prepare(query)
+	var err error
+
+	// new tasks go to the bottom of their column
+	task.Rank, err = bottomRank(tx, task.Status, 0)
 
 	if err != nil {
 		return err
 	}
 
-	defer stmt.Close()
+	err = task.trackSLAProgress(tx, nil)
 
-	result, err := stmt.Exec(task.Title, task.Description, task.Priority, task.Status, task.CreatedAt, task.UpdatedAt, task.DueDate, task.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, task.Title, task.Description, task.Priority, task.Status, task.CreatedAt, task.UpdatedAt, task.DueDate, nullableID(task.CategoryID), task.EstimateMinutes, task.Rank, task.RespondedAt, task.ResolvedAt, nullableID(task.ParentID))
 
 	if err != nil {
 		return err
 	}
 
 	// we have to insert the task and the users id as assignees
-	taskId, err := result.LastInsertId()
+	task.ID, err = result.LastInsertId()
+	task.Version = 1
 
 	if err != nil {
 		return err
 	}
 
-	if len(task.AssigneesIDs) > 0 {
-		taskUserQuery := `INSERT INTO tasks_assignees(task_id, user_id) VALUES(?, ?)`
+	return saveTaskRelations(tx, *task)
+}
+
+func (task Task) Update(actor Actor) error {
+	before, err := GetTask(task.ID)
+
+	if err != nil {
+		return err
+	}
 
-		assigneeStmt, err := db.DB.Prepare(taskUserQuery)
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		err := task.update(tx, *before)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []events.Event{TaskUpdated{Actor: actor, Before: *before, After: task}}, nil
+	})
+}
+
+// update writes the changes of the task over before, the caller records the event.
+// It fails with ErrVersionConflict when the task is not at task.Version anymore
+func (task *Task) update(tx *sql.Tx, before Task) error {
+	query := `UPDATE tasks SET title = ?, description = ?, priority = ?, status = ?, updated_at = ?, due_date = ?, category_id = ?, estimate_minutes = ?, board_rank = ?, responded_at = ?, resolved_at = ?, version = version + 1 WHERE id = ? AND version = ?`
+
+	var err error
+
+	// subtasks only get their parent from a template and archiving has its own endpoints
+	task.ParentID = before.ParentID
+	task.ArchivedAt = before.ArchivedAt
+
+	// the task keeps its place unless it changes column, then it goes to the bottom
+	task.Rank = before.Rank
+
+	if task.Status != before.Status {
+		task.Rank, err = bottomRank(tx, task.Status, task.ID)
 
 		if err != nil {
 			return err
 		}
+	}
 
-		defer assigneeStmt.Close()
+	err = task.trackSLAProgress(tx, &before)
 
-		for _, userId := range task.AssigneesIDs {
-			_, err := assigneeStmt.Exec(taskId, userId)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, task.Title, task.Description, task.Priority, task.Status, task.UpdatedAt, task.DueDate, nullableID(task.CategoryID), task.EstimateMinutes, task.Rank, task.RespondedAt, task.ResolvedAt, task.ID, task.Version)
+
+	if err != nil {
+		return err
+	}
+
+	err = checkVersion(result)
+
+	if err != nil {
+		return err
+	}
+
+	task.Version++
+
+	// assignees and labels sent on update replace the old ones
+	_, err = tx.Exec(`DELETE FROM tasks_assignees WHERE task_id = ?`, task.ID)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM tasks_labels WHERE task_id = ?`, task.ID)
 
-			if err != nil {
-				return err
-			}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM task_custom_values WHERE task_id = ?`, task.ID)
+
+	if err != nil {
+		return err
+	}
+
+	return saveTaskRelations(tx, *task)
+}
+
+func saveTaskRelations(tx *sql.Tx, task Task) error {
+	for _, userId := range uniqueInt64s(task.AssigneesIDs) {
+		_, err := tx.Exec(`INSERT INTO tasks_assignees(task_id, user_id) VALUES(?, ?)`, task.ID, userId)
+
+		if err != nil {
+			return err
 		}
 	}
 
+	for _, labelId := range uniqueInt64s(task.LabelIDs) {
+		_, err := tx.Exec(`INSERT INTO tasks_labels(task_id, label_id) VALUES(?, ?)`, task.ID, labelId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return saveTaskCustomValues(tx, task)
+}
+
+func GetTask(id int64) (*Task, error) {
+	return getTask(`SELECT `+taskColumns+` FROM tasks WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+// GetDeletedTask only finds tasks in the trash
+func GetDeletedTask(id int64) (*Task, error) {
+	return getTask(`SELECT `+taskColumns+` FROM tasks WHERE id = ? AND deleted_at IS NOT NULL`, id)
+}
+
+func getTask(query string, id int64) (*Task, error) {
+	row := db.DB.QueryRow(query, id)
+
+	task, err := scanTask(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = task.loadRelations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{*task}
+
+	err = applySLA(tasks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tasks[0], nil
+}
+
+func GetTasks(filter TaskFilter) ([]Task, error) {
+	query, args := filter.query(taskColumns)
+
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var allTasks []Task
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allTasks = append(allTasks, *task)
+	}
+
+	// load the relations after the rows are closed, sqlite only has a few connections
+	rows.Close()
+
+	err = completeTasks(allTasks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.keep(allTasks), nil
+}
+
+// CountTasks counts the tasks of the filter without loading them. The filters worked out
+// after the query are not applied, with those it is the most tasks there can be
+func CountTasks(filter TaskFilter) (int, error) {
+	query, args := filter.query("id")
+
+	var count int
+
+	err := db.DB.QueryRow(`SELECT COUNT(*) FROM (`+query+`)`, args...).Scan(&count)
+
+	return count, err
+}
+
+// query selects the columns of the filtered tasks in the order of the list
+func (filter TaskFilter) query(columns string) (string, []any) {
+	query := `SELECT ` + columns + ` FROM tasks`
+
+	conditions := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if !filter.IncludeArchived {
+		conditions = append(conditions, "archived_at IS NULL")
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+
+	if filter.Priority != "" {
+		conditions = append(conditions, "priority = ?")
+		args = append(args, filter.Priority)
+	}
+
+	if filter.CategoryID != 0 {
+		conditions = append(conditions, "category_id = ?")
+		args = append(args, filter.CategoryID)
+	}
+
+	if filter.ParentID != 0 {
+		conditions = append(conditions, "parent_id = ?")
+		args = append(args, filter.ParentID)
+	}
+
+	if len(filter.LabelsAny) > 0 {
+		conditions = append(conditions, "id IN (SELECT task_id FROM tasks_labels WHERE label_id IN ("+placeholders(len(filter.LabelsAny))+"))")
+		args = append(args, int64sToArgs(filter.LabelsAny)...)
+	}
+
+	if len(filter.LabelsAll) > 0 {
+		labels := uniqueInt64s(filter.LabelsAll)
+
+		conditions = append(conditions, "id IN (SELECT task_id FROM tasks_labels WHERE label_id IN ("+placeholders(len(labels))+") GROUP BY task_id HAVING COUNT(DISTINCT label_id) = ?)")
+		args = append(args, int64sToArgs(labels)...)
+		args = append(args, len(labels))
+	}
+
+	if len(filter.LabelsNone) > 0 {
+		conditions = append(conditions, "id NOT IN (SELECT task_id FROM tasks_labels WHERE label_id IN ("+placeholders(len(filter.LabelsNone))+"))")
+		args = append(args, int64sToArgs(filter.LabelsNone)...)
+	}
+
+	for _, customFilter := range filter.CustomFields {
+		condition, conditionArgs := customFilter.condition()
+
+		conditions = append(conditions, condition)
+		args = append(args, conditionArgs...)
+	}
+
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
+	if filter.SortField != nil {
+		sortValue := "(SELECT json_extract(value, '$') FROM task_custom_values WHERE task_id = tasks.id AND field_id = ?)"
+		direction := "ASC"
+
+		if filter.SortDesc {
+			direction = "DESC"
+		}
+
+		query += " ORDER BY " + sortValue + " IS NULL, " + sortValue + " " + direction + ", due_date, id"
+		args = append(args, filter.SortField.ID, filter.SortField.ID)
+	} else {
+		query += " ORDER BY due_date, id"
+	}
+
+	return query, args
+}
+
+// keep applies the filters that are worked out after the query
+func (filter TaskFilter) keep(tasks []Task) []Task {
+	if !filter.SLABreached {
+		return tasks
+	}
+
+	var breachedTasks []Task
+
+	for _, task := range tasks {
+		if task.SLA != nil && task.SLA.State == SLABreached {
+			breachedTasks = append(breachedTasks, task)
+		}
+	}
+
+	return breachedTasks
+}
+
+// completeTasks loads the relations and works out the sla of the scanned tasks
+func completeTasks(tasks []Task) error {
+	for i := range tasks {
+		err := tasks[i].loadRelations()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return applySLA(tasks)
+}
+
+// Delete moves the task to the trash, PurgeTask deletes it for good
+func (task Task) Delete(actor Actor) error {
+	return DeleteTasks([]Task{task}, actor)
+}
+
+// Restore takes the task out of the trash
+func (task Task) Restore(actor Actor) error {
+	query := `UPDATE tasks SET deleted_at = NULL, version = version + 1 WHERE id = ? AND version = ?`
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, task.ID, task.Version)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = checkVersion(result)
+
+		if err != nil {
+			return nil, err
+		}
+
+		task.DeletedAt = nil
+		task.Version++
+
+		return []events.Event{TaskRestored{Actor: actor, Task: task}}, nil
+	})
+}
+
+// GetDeletedTasks returns the trash, the latest deleted first. With a non zero
+// time only the ones deleted before it are returned
+func GetDeletedTasks(deletedBefore time.Time) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	var args []any
+
+	if !deletedBefore.IsZero() {
+		query = `SELECT ` + taskColumns + ` FROM tasks WHERE deleted_at < ? ORDER BY deleted_at DESC`
+		args = append(args, deletedBefore)
+	}
+
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var deletedTasks []Task
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deletedTasks = append(deletedTasks, *task)
+	}
+
+	rows.Close()
+
+	for i := range deletedTasks {
+		err = deletedTasks[i].loadRelations()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return deletedTasks, nil
+}
+
+// PurgeTask deletes a task of the trash for good with its rows in the other tables,
+// they are deleted here rather than left to the foreign keys so every row the purge
+// touches is listed in one place. The audit trail is kept and the
+// files of the attachments stay in the storage, the caller removes them once this returns
+func PurgeTask(taskId int64) error {
+	tx, err := db.DB.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM tasks_assignees WHERE task_id = ?`,
+		`DELETE FROM tasks_labels WHERE task_id = ?`,
+		`DELETE FROM task_custom_values WHERE task_id = ?`,
+		`DELETE FROM checklist_items WHERE task_id = ?`,
+		`DELETE FROM time_entries WHERE task_id = ?`,
+		`DELETE FROM comments WHERE task_id = ?`,
+		`DELETE FROM notifications WHERE task_id = ?`,
+		`DELETE FROM task_reminders WHERE task_id = ?`,
+		`DELETE FROM attachments WHERE task_id = ?`,
+		// the subtasks stay as tasks of their own
+		`UPDATE tasks SET parent_id = NULL, version = version + 1 WHERE parent_id = ?`,
+		`DELETE FROM tasks WHERE id = ?`,
+	}
+
+	for _, query := range queries {
+		_, err = tx.Exec(query, taskId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const taskColumns = `id, title, COALESCE(description, ''), priority, status, created_at, updated_at, due_date, COALESCE(category_id, 0), estimate_minutes, board_rank, responded_at, resolved_at, COALESCE(parent_id, 0), deleted_at, archived_at, version`
+
+func scanTask(row rowScanner) (*Task, error) {
+	var task Task
+	var respondedAt, resolvedAt, deletedAt, archivedAt sql.NullTime
+
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Priority, &task.Status, &task.CreatedAt, &task.UpdatedAt, &task.DueDate, &task.CategoryID, &task.EstimateMinutes, &task.Rank, &respondedAt, &resolvedAt, &task.ParentID, &deletedAt, &archivedAt, &task.Version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if respondedAt.Valid {
+		task.RespondedAt = &respondedAt.Time
+	}
+
+	if resolvedAt.Valid {
+		task.ResolvedAt = &resolvedAt.Time
+	}
+
+	if deletedAt.Valid {
+		task.DeletedAt = &deletedAt.Time
+	}
+
+	if archivedAt.Valid {
+		task.ArchivedAt = &archivedAt.Time
+	}
+
+	return &task, nil
+}
+
+// trackSLAProgress stamps the response and resolution times from the status category,
+// the stamps of the task before the change are kept. Reopening a task clears its resolution
+func (task *Task) trackSLAProgress(tx *sql.Tx, before *Task) error {
+	task.RespondedAt, task.ResolvedAt = nil, nil
+
+	if before != nil {
+		task.RespondedAt, task.ResolvedAt = before.RespondedAt, before.ResolvedAt
+	}
+
+	var category StatusCategory
+
+	err := tx.QueryRow(`SELECT category FROM statuses WHERE name = ?`, task.Status).Scan(&category)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	if category != StatusCategoryOpen && task.RespondedAt == nil {
+		task.RespondedAt = &now
+	}
+
+	if category != StatusCategoryClosed {
+		task.ResolvedAt = nil
+	} else if task.ResolvedAt == nil {
+		task.ResolvedAt = &now
+	}
+
 	return nil
 }
+
+func (task *Task) loadRelations() error {
+	var err error
+
+	task.AssigneesIDs, err = getTaskAssigneesIDs(task.ID)
+
+	if err != nil {
+		return err
+	}
+
+	task.LabelIDs, err = getTaskLabelIDs(task.ID)
+
+	if err != nil {
+		return err
+	}
+
+	task.ChecklistProgress, err = getChecklistProgress(task.ID)
+
+	if err != nil {
+		return err
+	}
+
+	task.CustomFields, err = getTaskCustomValues(task.ID)
+
+	return err
+}
+
+func getTaskAssigneesIDs(taskId int64) ([]int64, error) {
+	query := `SELECT user_id FROM tasks_assignees WHERE task_id = ?`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var assigneesIDs []int64
+
+	for rows.Next() {
+		var userId int64
+
+		err := rows.Scan(&userId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		assigneesIDs = append(assigneesIDs, userId)
+	}
+
+	return assigneesIDs, nil
+}
+
+func getTaskLabelIDs(taskId int64) ([]int64, error) {
+	query := `SELECT label_id FROM tasks_labels WHERE task_id = ?`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var labelIDs []int64
+
+	for rows.Next() {
+		var labelId int64
+
+		err := rows.Scan(&labelId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		labelIDs = append(labelIDs, labelId)
+	}
+
+	return labelIDs, nil
+}
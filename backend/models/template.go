@@ -0,0 +1,341 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
+)
+
+// variables are written like {{name}} in the texts of a template
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// the start date of the instance is always there as {{date}}
+const templateDateVariable = "date"
+
+const templateDateLayout = "2006-01-02"
+
+// TaskTemplate creates a task, its checklist and its subtasks in one go. The texts can have
+// {{variables}} and the due dates are counted in days from the day it is instantiated
+type TaskTemplate struct {
+	ID             int64             `json:"id"`
+	Name           string            `json:"name" binding:"required,min=3,max=80"`
+	TitlePattern   string            `json:"title_pattern" binding:"required,min=3"`
+	Description    string            `json:"description"`
+	Priority       Priority          `json:"priority" binding:"required"`
+	CategoryID     int64             `json:"category_id" binding:"min=0"`
+	DueInDays      int               `json:"due_in_days" binding:"min=0"`
+	AssigneesIDs   []int64           `json:"assignees_ids"`
+	LabelIDs       []int64           `json:"label_ids"`
+	ChecklistItems []string          `json:"checklist_items" binding:"dive,min=1,max=250"`
+	Subtasks       []TemplateSubtask `json:"subtasks" binding:"dive"`
+	CustomFields   map[string]any    `json:"custom_fields"`
+	CreatedBy      int64             `json:"created_by"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// TemplateSubtask becomes a task with the template task as its parent,
+// it takes the priority of the template when it has none
+type TemplateSubtask struct {
+	TitlePattern   string   `json:"title_pattern" binding:"required,min=3"`
+	Description    string   `json:"description"`
+	Priority       Priority `json:"priority"`
+	DueInDays      int      `json:"due_in_days" binding:"min=0"`
+	AssigneesIDs   []int64  `json:"assignees_ids"`
+	ChecklistItems []string `json:"checklist_items" binding:"dive,min=1,max=250"`
+}
+
+// TemplateInstance is what is sent to instantiate a template
+type TemplateInstance struct {
+	Variables map[string]string `json:"variables"`
+	// the day the due dates are counted from like 2024-01-31, today when empty
+	StartDate string `json:"start_date"`
+}
+
+func (template *TaskTemplate) Save() error {
+	template.CreatedAt = time.Now().UTC()
+
+	lists, err := template.encodeLists()
+
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO task_templates(name, title_pattern, description, priority, category_id, due_in_days, assignees_ids, label_ids, checklist_items, subtasks, custom_fields, created_by, created_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	args := []any{template.Name, template.TitlePattern, template.Description, template.Priority, nullableID(template.CategoryID), template.DueInDays}
+	args = append(args, lists...)
+	args = append(args, template.CreatedBy, template.CreatedAt)
+
+	result, err := db.DB.Exec(query, args...)
+
+	if err != nil {
+		return err
+	}
+
+	template.ID, err = result.LastInsertId()
+
+	return err
+}
+
+func (template TaskTemplate) Update() error {
+	lists, err := template.encodeLists()
+
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE task_templates SET name = ?, title_pattern = ?, description = ?, priority = ?, category_id = ?, due_in_days = ?, assignees_ids = ?, label_ids = ?, checklist_items = ?, subtasks = ?, custom_fields = ? WHERE id = ?`
+
+	args := []any{template.Name, template.TitlePattern, template.Description, template.Priority, nullableID(template.CategoryID), template.DueInDays}
+	args = append(args, lists...)
+	args = append(args, template.ID)
+
+	_, err = db.DB.Exec(query, args...)
+
+	return err
+}
+
+func (template TaskTemplate) Delete() error {
+	_, err := db.DB.Exec(`DELETE FROM task_templates WHERE id = ?`, template.ID)
+
+	return err
+}
+
+// encodeLists returns the json of assignees, labels, checklist, subtasks and custom fields in column order
+func (template TaskTemplate) encodeLists() ([]any, error) {
+	lists := []any{
+		orEmpty(template.AssigneesIDs),
+		orEmpty(template.LabelIDs),
+		orEmpty(template.ChecklistItems),
+		orEmpty(template.Subtasks),
+		template.CustomFields,
+	}
+
+	if template.CustomFields == nil {
+		lists[4] = map[string]any{}
+	}
+
+	for i, list := range lists {
+		encoded, err := json.Marshal(list)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lists[i] = string(encoded)
+	}
+
+	return lists, nil
+}
+
+func orEmpty[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+
+	return list
+}
+
+// Variables returns the names of the variables the template needs, {{date}} is left out
+func (template TaskTemplate) Variables() []string {
+	texts := []string{template.TitlePattern, template.Description}
+	texts = append(texts, template.ChecklistItems...)
+
+	for _, subtask := range template.Subtasks {
+		texts = append(texts, subtask.TitlePattern, subtask.Description)
+		texts = append(texts, subtask.ChecklistItems...)
+	}
+
+	var names []string
+
+	for _, text := range texts {
+		for _, match := range templateVariablePattern.FindAllStringSubmatch(text, -1) {
+			if match[1] != templateDateVariable && !slices.Contains(names, match[1]) {
+				names = append(names, match[1])
+			}
+		}
+	}
+
+	return names
+}
+
+// Instantiate creates the task of the template with its checklist and subtasks in one transaction.
+// The variables have to be checked before, missing ones are left as they are
+func (template TaskTemplate) Instantiate(instance TemplateInstance, startDate time.Time, actor Actor) (*Task, error) {
+	variables := map[string]string{templateDateVariable: startDate.Format(templateDateLayout)}
+
+	for name, value := range instance.Variables {
+		if name != templateDateVariable {
+			variables[name] = value
+		}
+	}
+
+	status, err := GetStartStatus()
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	task := Task{
+		Title:        fillTemplateText(template.TitlePattern, variables),
+		Description:  fillTemplateText(template.Description, variables),
+		Priority:     template.Priority,
+		Status:       status.Name,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		DueDate:      startDate.AddDate(0, 0, template.DueInDays),
+		CategoryID:   template.CategoryID,
+		AssigneesIDs: template.AssigneesIDs,
+		LabelIDs:     template.LabelIDs,
+		CustomFields: template.CustomFields,
+	}
+
+	err = inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		err := task.insert(tx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = insertTemplateChecklist(tx, task.ID, template.ChecklistItems, variables)
+
+		if err != nil {
+			return nil, err
+		}
+
+		createdEvents := []events.Event{TaskCreated{Actor: actor, Task: task}}
+
+		for _, templateSubtask := range template.Subtasks {
+			subtask := Task{
+				Title:        fillTemplateText(templateSubtask.TitlePattern, variables),
+				Description:  fillTemplateText(templateSubtask.Description, variables),
+				Priority:     templateSubtask.Priority,
+				Status:       status.Name,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+				DueDate:      startDate.AddDate(0, 0, templateSubtask.DueInDays),
+				CategoryID:   template.CategoryID,
+				AssigneesIDs: templateSubtask.AssigneesIDs,
+				LabelIDs:     template.LabelIDs,
+				CustomFields: template.CustomFields,
+				ParentID:     task.ID,
+			}
+
+			if subtask.Priority == "" {
+				subtask.Priority = template.Priority
+			}
+
+			err = subtask.insert(tx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			err = insertTemplateChecklist(tx, subtask.ID, templateSubtask.ChecklistItems, variables)
+
+			if err != nil {
+				return nil, err
+			}
+
+			createdEvents = append(createdEvents, TaskCreated{Actor: actor, Task: subtask})
+		}
+
+		return createdEvents, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func insertTemplateChecklist(tx *sql.Tx, taskId int64, items []string, variables map[string]string) error {
+	for i, item := range items {
+		_, err := tx.Exec(`INSERT INTO checklist_items(task_id, text, position) VALUES(?, ?, ?)`, taskId, fillTemplateText(item, variables), i+1)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fillTemplateText puts the values in place of the {{variables}} it knows
+func fillTemplateText(text string, variables map[string]string) string {
+	return templateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+
+		value, ok := variables[name]
+
+		if !ok {
+			return match
+		}
+
+		return value
+	})
+}
+
+const taskTemplateColumns = `id, name, title_pattern, description, priority, COALESCE(category_id, 0), due_in_days, assignees_ids, label_ids, checklist_items, subtasks, custom_fields, COALESCE(created_by, 0), created_at`
+
+func scanTaskTemplate(row rowScanner) (*TaskTemplate, error) {
+	var template TaskTemplate
+	var assigneesIDs, labelIDs, checklistItems, subtasks, customFields string
+
+	err := row.Scan(&template.ID, &template.Name, &template.TitlePattern, &template.Description, &template.Priority, &template.CategoryID, &template.DueInDays, &assigneesIDs, &labelIDs, &checklistItems, &subtasks, &customFields, &template.CreatedBy, &template.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	encodedLists := []string{assigneesIDs, labelIDs, checklistItems, subtasks, customFields}
+	targets := []any{&template.AssigneesIDs, &template.LabelIDs, &template.ChecklistItems, &template.Subtasks, &template.CustomFields}
+
+	for i, encoded := range encodedLists {
+		err = json.Unmarshal([]byte(encoded), targets[i])
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &template, nil
+}
+
+func GetTaskTemplate(id int64) (*TaskTemplate, error) {
+	row := db.DB.QueryRow(`SELECT `+taskTemplateColumns+` FROM task_templates WHERE id = ?`, id)
+
+	return scanTaskTemplate(row)
+}
+
+func GetTaskTemplates() ([]TaskTemplate, error) {
+	rows, err := db.DB.Query(`SELECT ` + taskTemplateColumns + ` FROM task_templates ORDER BY name`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var templates []TaskTemplate
+
+	for rows.Next() {
+		template, err := scanTaskTemplate(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		templates = append(templates, *template)
+	}
+
+	return templates, nil
+}
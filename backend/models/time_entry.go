@@ -0,0 +1,281 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+type TimeEntry struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	UserID    int64      `json:"user_id"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+	Minutes   int64      `json:"minutes"`
+	Note      string     `json:"note"`
+}
+
+// ManualTimeEntry is the body of a time entry logged by hand instead of a timer
+type ManualTimeEntry struct {
+	Minutes   int64     `json:"minutes" binding:"required,min=1"`
+	StartedAt time.Time `json:"started_at"`
+	Note      string    `json:"note" binding:"max=250"`
+}
+
+type TimeReportRow struct {
+	Key              string `json:"key"`
+	Label            string `json:"label"`
+	LoggedMinutes    int64  `json:"logged_minutes"`
+	EstimatedMinutes int64  `json:"estimated_minutes"`
+}
+
+var ErrTimerAlreadyRunning = errors.New("a timer is already running")
+var ErrNoRunningTimer = errors.New("no timer is running")
+
+const timeEntryColumns = `id, task_id, COALESCE(user_id, 0), started_at, ended_at, minutes, COALESCE(note, '')`
+
+// StartTimer fails with ErrTimerAlreadyRunning when the user has a running timer,
+// the partial unique index on time_entries makes sure of that even for parallel requests
+func StartTimer(taskId, userId int64) (*TimeEntry, error) {
+	running, err := GetRunningTimer(userId)
+
+	if err == nil {
+		return running, ErrTimerAlreadyRunning
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	entry := TimeEntry{
+		TaskID:    taskId,
+		UserID:    userId,
+		StartedAt: time.Now().UTC(),
+	}
+
+	query := `INSERT INTO time_entries(task_id, user_id, started_at) VALUES(?, ?, ?)`
+
+	result, err := db.DB.Exec(query, entry.TaskID, entry.UserID, entry.StartedAt)
+
+	if err != nil {
+		running, runningErr := GetRunningTimer(userId)
+
+		if runningErr == nil {
+			return running, ErrTimerAlreadyRunning
+		}
+
+		return nil, err
+	}
+
+	entry.ID, err = result.LastInsertId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// StopTimer stops the running timer of the user, started minutes are rounded up
+func StopTimer(userId int64, note string) (*TimeEntry, error) {
+	entry, err := GetRunningTimer(userId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoRunningTimer
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	endedAt := time.Now().UTC()
+	entry.EndedAt = &endedAt
+	entry.Minutes = int64(math.Ceil(endedAt.Sub(entry.StartedAt).Minutes()))
+
+	if note != "" {
+		entry.Note = note
+	}
+
+	query := `UPDATE time_entries SET ended_at = ?, minutes = ?, note = ? WHERE id = ? AND ended_at IS NULL`
+
+	result, err := db.DB.Exec(query, entry.EndedAt, entry.Minutes, entry.Note, entry.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updatedRows, err := result.RowsAffected()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a stop request that came in at the same time stopped it first
+	if updatedRows == 0 {
+		return nil, ErrNoRunningTimer
+	}
+
+	return entry, nil
+}
+
+func (manual ManualTimeEntry) Save(taskId, userId int64) (*TimeEntry, error) {
+	startedAt := manual.StartedAt
+
+	if startedAt.IsZero() {
+		startedAt = time.Now().Add(-time.Duration(manual.Minutes) * time.Minute)
+	}
+
+	endedAt := startedAt.Add(time.Duration(manual.Minutes) * time.Minute).UTC()
+
+	entry := TimeEntry{
+		TaskID:    taskId,
+		UserID:    userId,
+		StartedAt: startedAt.UTC(),
+		EndedAt:   &endedAt,
+		Minutes:   manual.Minutes,
+		Note:      manual.Note,
+	}
+
+	query := `INSERT INTO time_entries(task_id, user_id, started_at, ended_at, minutes, note) VALUES(?, ?, ?, ?, ?, ?)`
+
+	result, err := db.DB.Exec(query, entry.TaskID, entry.UserID, entry.StartedAt, entry.EndedAt, entry.Minutes, entry.Note)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry.ID, err = result.LastInsertId()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (entry TimeEntry) Delete() error {
+	query := `DELETE FROM time_entries WHERE id = ?`
+
+	_, err := db.DB.Exec(query, entry.ID)
+
+	return err
+}
+
+func GetTimeEntry(id int64) (*TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE id = ?`
+
+	return scanTimeEntry(db.DB.QueryRow(query, id))
+}
+
+func GetRunningTimer(userId int64) (*TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE user_id = ? AND ended_at IS NULL`
+
+	return scanTimeEntry(db.DB.QueryRow(query, userId))
+}
+
+func GetTaskTimeEntries(taskId int64) ([]TimeEntry, error) {
+	query := `SELECT ` + timeEntryColumns + ` FROM time_entries WHERE task_id = ? ORDER BY started_at`
+
+	rows, err := db.DB.Query(query, taskId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []TimeEntry
+
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// report groups: the key expression and how to turn a key into a readable label
+var timeReportGroups = map[string]struct {
+	key   string
+	label string
+}{
+	"user":     {key: "COALESCE(e.user_id, 0)", label: "COALESCE((SELECT first_name || ' ' || last_name FROM users WHERE id = l.group_key), 'Deleted user')"},
+	"category": {key: "COALESCE(t.category_id, 0)", label: "COALESCE((SELECT title FROM categories WHERE id = l.group_key), 'No category')"},
+	"date":     {key: "date(e.started_at)", label: "l.group_key"},
+}
+
+// GetTimeReport sums the finished time entries started in [from, to) per group,
+// estimated minutes are the estimates of the distinct tasks worked on in that group
+func GetTimeReport(groupBy string, from, to time.Time) ([]TimeReportRow, error) {
+	group, ok := timeReportGroups[groupBy]
+
+	if !ok {
+		return nil, errors.New("group_by must be one of user, category, date")
+	}
+
+	query := `
+		WITH entries AS (
+			SELECT ` + group.key + ` AS group_key, e.task_id, e.minutes, t.estimate_minutes AS estimate
+			FROM time_entries e JOIN tasks t ON t.id = e.task_id
+			WHERE e.ended_at IS NOT NULL AND e.started_at >= ? AND e.started_at < ?
+		)
+		SELECT CAST(l.group_key AS TEXT), COALESCE(` + group.label + `, ''), l.logged, es.estimated
+		FROM (SELECT group_key, SUM(minutes) AS logged FROM entries GROUP BY group_key) l
+		JOIN (
+			SELECT group_key, SUM(estimate) AS estimated
+			FROM (SELECT DISTINCT group_key, task_id, estimate FROM entries)
+			GROUP BY group_key
+		) es ON es.group_key = l.group_key
+		ORDER BY l.group_key
+	`
+
+	rows, err := db.DB.Query(query, from.UTC(), to.UTC())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var report []TimeReportRow
+
+	for rows.Next() {
+		var row TimeReportRow
+
+		err := rows.Scan(&row.Key, &row.Label, &row.LoggedMinutes, &row.EstimatedMinutes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		report = append(report, row)
+	}
+
+	return report, nil
+}
+
+func scanTimeEntry(row rowScanner) (*TimeEntry, error) {
+	var entry TimeEntry
+	var endedAt sql.NullTime
+
+	err := row.Scan(&entry.ID, &entry.TaskID, &entry.UserID, &entry.StartedAt, &endedAt, &entry.Minutes, &entry.Note)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if endedAt.Valid {
+		entry.EndedAt = &endedAt.Time
+	}
+
+	return &entry, nil
+}
@@ -1,19 +1,24 @@
 package models
 
 import (
+	"database/sql"
 	"errors"
+	"slices"
+	"time"
 
 	"github.com/abolfazlcodes/task-dashboard/backend/db"
+	"github.com/abolfazlcodes/task-dashboard/backend/events"
 	"github.com/abolfazlcodes/task-dashboard/backend/utils"
 )
 
 type User struct {
 	ID        int64
-	FirstName string `json:"first_name" binding:"required,min=3"`
-	LastName  string `json:"last_name" binding:"required,min=3"`
-	UserName  string `json:"username"`
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	FirstName string     `json:"first_name" binding:"required,min=3"`
+	LastName  string     `json:"last_name" binding:"required,min=3"`
+	UserName  string     `json:"username"`
+	Email     string     `json:"email" binding:"required,email"`
+	Password  string     `json:"password" binding:"required,min=6"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" binding:"-"`
 }
 
 type LoginUser struct {
@@ -22,43 +27,45 @@ type LoginUser struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
-func (user User) Save() error {
-	query := `INSERT INTO users(first_name, last_name, username, email, password) VALUES(?, ?, ?, ?, ?)`
-
-	stmt, err := db.DB.Prepare(query)
+// Save signs the user up, on sign up nobody is logged in so when the actor
+// has no user the new user is the actor
+func (user *User) Save(actor Actor) error {
+	query := `INSERT INTO users(first_name, last_name, username, email, password, is_admin) VALUES(?, ?, ?, ?, ?, ?)`
 
+	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
 		return err
 	}
 
-	defer stmt.Close()
+	user.UserName = utils.GenerateUsername(user.Email)
 
-	hashedPassword, err := utils.HashPassword(user.Password)
-	if err != nil {
-		return err
-	}
+	// admins are bootstrapped from the ADMIN_EMAILS env, nobody can sign up as one
+	isAdmin := slices.Contains(utils.GetEnvList("ADMIN_EMAILS", nil), user.Email)
 
-	generatedUsername := utils.GenerateUsername(user.Email)
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		result, err := tx.Exec(query, user.FirstName, user.LastName, user.UserName, user.Email, hashedPassword, isAdmin)
 
-	result, err := stmt.Exec(user.FirstName, user.LastName, generatedUsername, user.Email, hashedPassword)
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		return err
-	}
+		// set the user id to the id created by DB
+		user.ID, err = result.LastInsertId()
 
-	userId, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		return err
-	}
-	// set the user id to the id created by DB
-	user.ID = userId
+		if actor.UserID == 0 {
+			actor.UserID = user.ID
+		}
 
-	return nil
+		return []events.Event{UserCreated{Actor: actor, User: user.withoutPassword()}}, nil
+	})
 }
 
-func (user LoginUser) ValidateCredentials() error {
-	query := `SELECT id, password FROM users WHERE email = ?`
+func (user *LoginUser) ValidateCredentials() error {
+	query := `SELECT id, password FROM users WHERE email = ? AND deleted_at IS NULL`
 
 	row := db.DB.QueryRow(query, user.Email)
 
@@ -79,27 +86,89 @@ func (user LoginUser) ValidateCredentials() error {
 	return nil
 }
 
-func (user User) Delete() error {
-	query := "DELETE FROM users WHERE id = ?"
-	stmt, err := db.DB.Prepare(query)
+// Delete moves the user to the trash, a deleted user can't log in anymore and
+// PurgeUser deletes it for good
+func (user User) Delete(actor Actor) error {
+	query := `UPDATE users SET deleted_at = ? WHERE id = ?`
+
+	now := time.Now().UTC()
+	user.DeletedAt = &now
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		_, err := tx.Exec(query, user.DeletedAt, user.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []events.Event{UserDeleted{Actor: actor, User: user.withoutPassword()}}, nil
+	})
+}
+
+// Restore takes the user out of the trash
+func (user User) Restore(actor Actor) error {
+	query := `UPDATE users SET deleted_at = NULL WHERE id = ?`
+
+	user.DeletedAt = nil
+
+	return inTransaction(func(tx *sql.Tx) ([]events.Event, error) {
+		_, err := tx.Exec(query, user.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []events.Event{UserRestored{Actor: actor, User: user.withoutPassword()}}, nil
+	})
+}
+
+// PurgeUser deletes a user of the trash for good with the assignments, the settings and
+// the running timer of the user. The audit trail, the comments, the logged time and the
+// templates are kept, their user id is set to NULL (0 in the api)
+func PurgeUser(userId int64) error {
+	tx, err := db.DB.Begin()
 
 	if err != nil {
 		return err
 	}
 
-	defer stmt.Close()
+	defer tx.Rollback()
+
+	queries := []string{
+		`UPDATE tasks SET version = version + 1 WHERE id IN (SELECT task_id FROM tasks_assignees WHERE user_id = ?)`,
+		`DELETE FROM tasks_assignees WHERE user_id = ?`,
+		`DELETE FROM notifications WHERE user_id = ?`,
+		`DELETE FROM notification_preferences WHERE user_id = ?`,
+		`DELETE FROM digest_settings WHERE user_id = ?`,
+		`DELETE FROM task_reminders WHERE user_id = ?`,
+		`DELETE FROM time_entries WHERE user_id = ? AND ended_at IS NULL`,
+		`DELETE FROM users WHERE id = ?`,
+	}
+
+	for _, query := range queries {
+		_, err = tx.Exec(query, userId)
 
-	_, err = stmt.Exec(user.ID)
-	return err
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (user User) withoutPassword() User {
+	user.Password = ""
+
+	return user
 }
 
 func GetUser(userId int64) (*User, error) {
-	query := `SELECT * FROM users WHERE id = ?`
+	query := `SELECT id, first_name, last_name, email, password, username FROM users WHERE id = ? AND deleted_at IS NULL`
 	row := db.DB.QueryRow(query, userId)
 
 	var user User
 
-	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Password, &user.UserName, &user.Email)
+	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.UserName)
 
 	if err != nil {
 		return nil, err
@@ -108,9 +177,65 @@ func GetUser(userId int64) (*User, error) {
 	return &user, nil
 }
 
+// GetDeletedUser only finds users in the trash, the password is left out
+func GetDeletedUser(userId int64) (*User, error) {
+	query := `SELECT id, first_name, last_name, email, username, deleted_at FROM users WHERE id = ? AND deleted_at IS NOT NULL`
+
+	return scanDeletedUser(db.DB.QueryRow(query, userId))
+}
+
+// GetDeletedUsers returns the trash, the latest deleted first. With a non zero
+// time only the ones deleted before it are returned
+func GetDeletedUsers(deletedBefore time.Time) ([]User, error) {
+	query := `SELECT id, first_name, last_name, email, username, deleted_at FROM users WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	var args []any
+
+	if !deletedBefore.IsZero() {
+		query = `SELECT id, first_name, last_name, email, username, deleted_at FROM users WHERE deleted_at < ? ORDER BY deleted_at DESC`
+		args = append(args, deletedBefore)
+	}
+
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var deletedUsers []User
+
+	for rows.Next() {
+		user, err := scanDeletedUser(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deletedUsers = append(deletedUsers, *user)
+	}
+
+	return deletedUsers, nil
+}
+
+func scanDeletedUser(row rowScanner) (*User, error) {
+	var user User
+	var deletedAt time.Time
+
+	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.UserName, &deletedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	user.DeletedAt = &deletedAt
+
+	return &user, nil
+}
+
 func GetUsers() ([]User, error) {
 	// we should query just the fields we want from the db
-	query := `SELECT id, first_name, last_name, username FROM users`
+	query := `SELECT id, first_name, last_name, username FROM users WHERE deleted_at IS NULL`
 
 	rows, err := db.DB.Query(query)
 
@@ -136,3 +261,175 @@ func GetUsers() ([]User, error) {
 	}
 	return allUsersList, nil
 }
+
+func IsAdmin(userId int64) (bool, error) {
+	query := `SELECT is_admin FROM users WHERE id = ? AND deleted_at IS NULL`
+
+	var isAdmin bool
+
+	err := db.DB.QueryRow(query, userId).Scan(&isAdmin)
+
+	return isAdmin, err
+}
+
+// GetAdminIDs returns the ids of the admins that are not in the trash
+func GetAdminIDs() ([]int64, error) {
+	rows, err := db.DB.Query(`SELECT id FROM users WHERE is_admin AND deleted_at IS NULL`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var userIds []int64
+
+	for rows.Next() {
+		var userId int64
+
+		err := rows.Scan(&userId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, nil
+}
+
+// UsersExist tells if every id is a user that is not in the trash
+func UsersExist(ids []int64) (bool, error) {
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	query := `SELECT COUNT(DISTINCT id) FROM users WHERE deleted_at IS NULL AND id IN (` + placeholders(len(ids)) + `)`
+
+	var count int
+
+	err := db.DB.QueryRow(query, int64sToArgs(ids)...).Scan(&count)
+
+	if err != nil {
+		return false, err
+	}
+
+	return count == len(uniqueInt64s(ids)), nil
+}
+
+// GetUserNames returns the full names by user id, the users in the trash included
+// as their tasks still name them
+func GetUserNames() (map[int64]string, error) {
+	rows, err := db.DB.Query(`SELECT id, first_name || ' ' || last_name FROM users`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	names := make(map[int64]string)
+
+	for rows.Next() {
+		var userId int64
+		var name string
+
+		err := rows.Scan(&userId, &name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		names[userId] = name
+	}
+
+	return names, nil
+}
+
+// ErrAmbiguousLogin means more than one user goes by the login, usernames are not unique
+var ErrAmbiguousLogin = errors.New("more than one user has this login")
+
+// GetUserIDByLogin finds the user by email, ignoring the case, and only when no email
+// matches by username. A username is case sensitive
+func GetUserIDByLogin(login string) (int64, error) {
+	queries := []string{
+		`SELECT id FROM users WHERE deleted_at IS NULL AND email = ? COLLATE NOCASE LIMIT 2`,
+		`SELECT id FROM users WHERE deleted_at IS NULL AND username = ? LIMIT 2`,
+	}
+
+	for _, query := range queries {
+		rows, err := db.DB.Query(query, login)
+
+		if err != nil {
+			return 0, err
+		}
+
+		var userIds []int64
+
+		for rows.Next() {
+			var userId int64
+
+			err = rows.Scan(&userId)
+
+			if err != nil {
+				rows.Close()
+				return 0, err
+			}
+
+			userIds = append(userIds, userId)
+		}
+
+		rows.Close()
+
+		if len(userIds) > 1 {
+			return 0, ErrAmbiguousLogin
+		}
+
+		if len(userIds) == 1 {
+			return userIds[0], nil
+		}
+	}
+
+	return 0, sql.ErrNoRows
+}
+
+// GetUserIDsByUsernames returns the users of the usernames, usernames are not unique
+// and the ones more than one user has are skipped as there is no telling who was meant
+func GetUserIDsByUsernames(usernames []string) ([]int64, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT MIN(id) FROM users WHERE deleted_at IS NULL AND username IN (` + placeholders(len(usernames)) + `) GROUP BY username HAVING COUNT(*) = 1`
+
+	args := make([]any, len(usernames))
+
+	for i, username := range usernames {
+		args[i] = username
+	}
+
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var userIds []int64
+
+	for rows.Next() {
+		var userId int64
+
+		err := rows.Scan(&userId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, nil
+}
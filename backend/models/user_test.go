@@ -0,0 +1,138 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurgeUserKeepsTheirWork(t *testing.T) {
+	user := User{FirstName: "Sara", LastName: "Karimi", Email: "purged@example.com", Password: "secret1"}
+
+	err := user.Save(Actor{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := Task{Title: "Purge", Priority: "low", Status: "todo", DueDate: time.Now().UTC(), AssigneesIDs: []int64{user.ID}}
+
+	err = task.Save(Actor{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment := Comment{TaskID: task.ID, UserID: user.ID, Body: "still here"}
+
+	err = comment.Save(Actor{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := TaskTemplate{Name: "Kept", TitlePattern: "Kept task", Priority: "low", CreatedBy: user.ID}
+
+	err = template.Save()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logged, err := ManualTimeEntry{Minutes: 30}.Save(task.ID, user.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = StartTimer(task.ID, user.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = PurgeUser(user.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := GetTaskComments(task.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comments) != 1 || comments[0].ID != comment.ID || comments[0].UserID != 0 {
+		t.Errorf("comments after the purge %+v, want comment %d without its user", comments, comment.ID)
+	}
+
+	keptTemplate, err := GetTaskTemplate(template.ID)
+
+	if err != nil {
+		t.Fatalf("the template is gone: %v", err)
+	}
+
+	if keptTemplate.CreatedBy != 0 {
+		t.Errorf("template created by %d, want 0", keptTemplate.CreatedBy)
+	}
+
+	entries, err := GetTaskTimeEntries(task.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the running timer goes with the user, the logged time stays
+	if len(entries) != 1 || entries[0].ID != logged.ID || entries[0].UserID != 0 {
+		t.Errorf("time entries after the purge %+v, want entry %d without its user", entries, logged.ID)
+	}
+
+	purgedTask, err := GetTask(task.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(purgedTask.AssigneesIDs) != 0 {
+		t.Errorf("the task is still assigned to %v", purgedTask.AssigneesIDs)
+	}
+}
+
+func TestGetUserIDsByUsernames(t *testing.T) {
+	ids := make(map[string]int64)
+
+	for _, email := range []string{"ali@one.example.com", "ali@two.example.com", "mina@example.com"} {
+		user := User{FirstName: "Mention", LastName: "Test", Email: email, Password: "secret1"}
+
+		err := user.Save(Actor{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids[email] = user.ID
+	}
+
+	tests := []struct {
+		usernames []string
+		want      []int64
+	}{
+		{[]string{"mina"}, []int64{ids["mina@example.com"]}},
+		{[]string{"ali"}, nil},
+		{[]string{"ali", "mina", "nobody"}, []int64{ids["mina@example.com"]}},
+		{nil, nil},
+	}
+
+	for _, test := range tests {
+		got, err := GetUserIDsByUsernames(test.usernames)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("GetUserIDsByUsernames(%v) = %v, want %v", test.usernames, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/db"
+)
+
+// event names shared by the webhooks and the realtime stream
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskUpdated       = "task.updated"
+	EventTaskStatusChanged = "task.status_changed"
+	EventTaskDeleted       = "task.deleted"
+	EventTaskRestored      = "task.restored"
+	EventCategoryCreated   = "category.created"
+	EventCategoryUpdated   = "category.updated"
+	EventCategoryDeleted   = "category.deleted"
+	EventCategoryRestored  = "category.restored"
+	EventUserCreated       = "user.created"
+	EventUserDeleted       = "user.deleted"
+	EventUserRestored      = "user.restored"
+	EventCommentCreated    = "comment.created"
+)
+
+var WebhookEventTypes = []string{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskStatusChanged,
+	EventTaskDeleted,
+	EventTaskRestored,
+	EventCategoryCreated,
+	EventCategoryUpdated,
+	EventCategoryDeleted,
+	EventCategoryRestored,
+	EventUserCreated,
+	EventUserDeleted,
+	EventUserRestored,
+}
+
+type Webhook struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url" binding:"required,url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types" binding:"required,min=1,dive,required"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	ID            int64                 `json:"id"`
+	WebhookID     int64                 `json:"webhook_id"`
+	EventID       string                `json:"event_id"`
+	EventType     string                `json:"event_type"`
+	Payload       json.RawMessage       `json:"payload"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	ResponseCode  int                   `json:"response_code"`
+	LastError     string                `json:"last_error"`
+	CreatedAt     time.Time             `json:"created_at"`
+	DeliveredAt   *time.Time            `json:"delivered_at"`
+}
+
+// WebhookPayload is the json body every endpoint receives
+type WebhookPayload struct {
+	EventID   string    `json:"event_id"`
+	EventType string    `json:"event_type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+func (webhook *Webhook) Save() error {
+	query := `INSERT INTO webhooks(url, secret, event_types, active, created_at) VALUES(?, ?, ?, ?, ?)`
+
+	webhook.CreatedAt = time.Now().UTC()
+
+	result, err := db.DB.Exec(query, webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Active, webhook.CreatedAt)
+
+	if err != nil {
+		return err
+	}
+
+	webhook.ID, err = result.LastInsertId()
+
+	return err
+}
+
+func (webhook Webhook) Update() error {
+	query := `UPDATE webhooks SET url = ?, secret = ?, event_types = ?, active = ? WHERE id = ?`
+
+	_, err := db.DB.Exec(query, webhook.URL, webhook.Secret, strings.Join(webhook.EventTypes, ","), webhook.Active, webhook.ID)
+
+	return err
+}
+
+// Delete removes the webhook, its deliveries go with it through the foreign key
+func (webhook Webhook) Delete() error {
+	_, err := db.DB.Exec(`DELETE FROM webhooks WHERE id = ?`, webhook.ID)
+
+	return err
+}
+
+func (webhook Webhook) Subscribes(eventType string) bool {
+	return slices.Contains(webhook.EventTypes, eventType) || slices.Contains(webhook.EventTypes, "*")
+}
+
+func GetWebhook(id int64) (*Webhook, error) {
+	query := `SELECT id, url, secret, event_types, active, created_at FROM webhooks WHERE id = ?`
+
+	return scanWebhook(db.DB.QueryRow(query, id))
+}
+
+func GetWebhooks(activeOnly bool) ([]Webhook, error) {
+	query := `SELECT id, url, secret, event_types, active, created_at FROM webhooks`
+
+	if activeOnly {
+		query += ` WHERE active = 1`
+	}
+
+	rows, err := db.DB.Query(query + ` ORDER BY id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var webhooks []Webhook
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, *webhook)
+	}
+
+	return webhooks, nil
+}
+
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var webhook Webhook
+	var eventTypes string
+
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.Active, &webhook.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.EventTypes = strings.Split(eventTypes, ",")
+
+	return &webhook, nil
+}
+
+// EnqueueWebhookEvent queues one delivery per active webhook subscribed to the event,
+// it returns how many deliveries were queued
+func EnqueueWebhookEvent(payload WebhookPayload) (int, error) {
+	webhooks, err := GetWebhooks(true)
+
+	if err != nil {
+		return 0, err
+	}
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(payload.EventType) {
+			continue
+		}
+
+		// the outbox hands the event over again when queueing failed half way
+		var exists bool
+
+		err = db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM webhook_deliveries WHERE webhook_id = ? AND event_id = ?)`, webhook.ID, payload.EventID).Scan(&exists)
+
+		if err != nil {
+			return queued, err
+		}
+
+		if exists {
+			continue
+		}
+
+		err = insertWebhookDelivery(webhook.ID, payload.EventID, payload.EventType, body)
+
+		if err != nil {
+			return queued, err
+		}
+
+		queued++
+	}
+
+	return queued, nil
+}
+
+// Redeliver queues a fresh copy of the delivery, the old one stays in the history
+func (delivery WebhookDelivery) Redeliver() error {
+	return insertWebhookDelivery(delivery.WebhookID, delivery.EventID, delivery.EventType, delivery.Payload)
+}
+
+func insertWebhookDelivery(webhookId int64, eventId, eventType string, payload []byte) error {
+	query := `INSERT INTO webhook_deliveries(webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at) VALUES(?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+
+	_, err := db.DB.Exec(query, webhookId, eventId, eventType, string(payload), DeliveryPending, now, now)
+
+	return err
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at, COALESCE(response_code, 0), COALESCE(last_error, ''), created_at, delivered_at`
+
+func GetWebhookDelivery(id int64) (*WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = ?`
+
+	return scanWebhookDelivery(db.DB.QueryRow(query, id))
+}
+
+func GetWebhookDeliveries(webhookId int64, limit, offset int) ([]WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	return queryWebhookDeliveries(query, webhookId, limit, offset)
+}
+
+// GetDueWebhookDeliveries returns the pending deliveries whose next attempt is due,
+// leaving out the ones of skipWebhookIds
+func GetDueWebhookDeliveries(now time.Time, limit int, skipWebhookIds []int64) ([]WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= ? AND webhook_id NOT IN (` + placeholders(len(skipWebhookIds)) + `) ORDER BY next_attempt_at LIMIT ?`
+
+	args := []any{DeliveryPending, now.UTC()}
+	args = append(args, int64sToArgs(skipWebhookIds)...)
+	args = append(args, limit)
+
+	return queryWebhookDeliveries(query, args...)
+}
+
+func queryWebhookDeliveries(query string, args ...any) ([]WebhookDelivery, error) {
+	rows, err := db.DB.Query(query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, nil
+}
+
+// SaveAttempt stores the outcome of a delivery attempt
+func (delivery WebhookDelivery) SaveAttempt() error {
+	query := `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, response_code = ?, last_error = ?, delivered_at = ? WHERE id = ?`
+
+	var responseCode any
+
+	if delivery.ResponseCode != 0 {
+		responseCode = delivery.ResponseCode
+	}
+
+	_, err := db.DB.Exec(query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt.UTC(), responseCode, delivery.LastError, delivery.DeliveredAt, delivery.ID)
+
+	return err
+}
+
+func scanWebhookDelivery(row rowScanner) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	var payload string
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.ResponseCode, &delivery.LastError, &delivery.CreatedAt, &deliveredAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+
+	return &delivery, nil
+}
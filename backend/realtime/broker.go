@@ -0,0 +1,148 @@
+package realtime
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+)
+
+// Event is a change pushed to the connected clients, UserIDs limits who receives it
+// and nil means every signed in user
+type Event struct {
+	ID        int64
+	Type      string
+	Data      any
+	UserIDs   []int64
+	CreatedAt time.Time
+}
+
+func (event Event) visibleTo(userId int64) bool {
+	return event.UserIDs == nil || slices.Contains(event.UserIDs, userId)
+}
+
+type Subscription struct {
+	Events <-chan Event
+	events chan Event
+	userId int64
+}
+
+// broker keeps the last events in a ring buffer so a reconnecting client can
+// resume from its Last-Event-ID instead of reloading everything
+type broker struct {
+	mutex       sync.Mutex
+	lastID      int64
+	log         []Event
+	logSize     int
+	subscribers map[*Subscription]struct{}
+}
+
+// subscriberBuffer is how far a client may fall behind before it is dropped,
+// a dropped client reconnects and catches up from the event log
+const subscriberBuffer = 64
+
+var defaultBroker = newBroker(1000)
+
+func newBroker(logSize int) *broker {
+	return &broker{
+		logSize:     logSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// InitBroker sizes the event log from EVENT_LOG_SIZE and sets how long soft locks
+// last with LOCK_TTL_SECONDS, it has to run before the server starts
+func InitBroker() {
+	defaultBroker = newBroker(int(utils.GetEnvInt64("EVENT_LOG_SIZE", 1000)))
+	defaultHub = newHub(time.Duration(utils.GetEnvInt64("LOCK_TTL_SECONDS", 60)) * time.Second)
+}
+
+func Publish(eventType string, data any) {
+	defaultBroker.publish(Event{Type: eventType, Data: data})
+}
+
+// PublishTo sends the event to the given users only
+func PublishTo(userIds []int64, eventType string, data any) {
+	defaultBroker.publish(Event{Type: eventType, Data: data, UserIDs: userIds})
+}
+
+// Subscribe returns the events after lastEventId the user missed and a subscription for
+// the new ones, resumed is false when the missed events are no longer in the log
+func Subscribe(userId, lastEventId int64) (missed []Event, resumed bool, subscription *Subscription) {
+	return defaultBroker.subscribe(userId, lastEventId)
+}
+
+func Unsubscribe(subscription *Subscription) {
+	defaultBroker.unsubscribe(subscription)
+}
+
+func (b *broker) publish(event Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastID++
+	event.ID = b.lastID
+	event.CreatedAt = time.Now().UTC()
+
+	b.log = append(b.log, event)
+
+	if len(b.log) > b.logSize {
+		b.log = slices.Delete(b.log, 0, len(b.log)-b.logSize)
+	}
+
+	for subscription := range b.subscribers {
+		if !event.visibleTo(subscription.userId) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			delete(b.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+}
+
+func (b *broker) subscribe(userId, lastEventId int64) ([]Event, bool, *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	events := make(chan Event, subscriberBuffer)
+	subscription := &Subscription{Events: events, events: events, userId: userId}
+	b.subscribers[subscription] = struct{}{}
+
+	if lastEventId <= 0 {
+		return nil, true, subscription
+	}
+
+	// an id we never handed out comes from before a restart
+	if lastEventId > b.lastID {
+		return nil, false, subscription
+	}
+
+	if len(b.log) > 0 && lastEventId < b.log[0].ID-1 {
+		return nil, false, subscription
+	}
+
+	var missed []Event
+
+	for _, event := range b.log {
+		if event.ID > lastEventId && event.visibleTo(userId) {
+			missed = append(missed, event)
+		}
+	}
+
+	return missed, true, subscription
+}
+
+func (b *broker) unsubscribe(subscription *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, ok := b.subscribers[subscription]; ok {
+		delete(b.subscribers, subscription)
+		close(subscription.events)
+	}
+}
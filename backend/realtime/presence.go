@@ -0,0 +1,365 @@
+package realtime
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"golang.org/x/net/websocket"
+)
+
+// a client has to send something (a ping at least) this often or it is disconnected
+const socketIdleTimeout = 90 * time.Second
+
+var roomPattern = regexp.MustCompile(`^(task:[0-9]+|board:[A-Za-z0-9_-]{1,64})$`)
+
+// SocketMessage is what clients send, e.g. {"type":"join","room":"task:12"} or {"type":"lock","task_id":12}
+type SocketMessage struct {
+	Type   string `json:"type"`
+	Room   string `json:"room"`
+	TaskID int64  `json:"task_id"`
+}
+
+type socketEvent struct {
+	Type      string     `json:"type"`
+	Room      string     `json:"room,omitempty"`
+	UserID    int64      `json:"user_id,omitempty"`
+	UserIDs   []int64    `json:"user_ids,omitempty"`
+	TaskID    int64      `json:"task_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Message   string     `json:"message,omitempty"`
+}
+
+type client struct {
+	userId int64
+	conn   *websocket.Conn
+	send   chan socketEvent
+	rooms  map[string]struct{}
+}
+
+// softLock only tells the others someone is editing the task, saving is never blocked by it
+type softLock struct {
+	holder    *client
+	expiresAt time.Time
+}
+
+type hub struct {
+	mutex   sync.Mutex
+	rooms   map[string]map[*client]struct{}
+	locks   map[int64]softLock
+	lockTTL time.Duration
+}
+
+var defaultHub = newHub(60 * time.Second)
+
+var startLockJanitor sync.Once
+
+func newHub(lockTTL time.Duration) *hub {
+	return &hub{
+		rooms:   make(map[string]map[*client]struct{}),
+		locks:   make(map[int64]softLock),
+		lockTTL: lockTTL,
+	}
+}
+
+// ServeSocket runs one websocket connection until the client goes away
+func ServeSocket(conn *websocket.Conn, userId int64) {
+	startLockJanitor.Do(func() {
+		go defaultHub.expireLocks()
+	})
+
+	c := &client{
+		userId: userId,
+		conn:   conn,
+		send:   make(chan socketEvent, 32),
+		rooms:  make(map[string]struct{}),
+	}
+
+	go c.writeLoop(c.send)
+	defer defaultHub.disconnect(c)
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(socketIdleTimeout))
+
+		var data string
+
+		err := websocket.Message.Receive(conn, &data)
+
+		if err != nil {
+			return
+		}
+
+		var message SocketMessage
+
+		err = json.Unmarshal([]byte(data), &message)
+
+		if err != nil {
+			defaultHub.reply(c, socketEvent{Type: "error", Message: "Messages have to be json objects."})
+			continue
+		}
+
+		defaultHub.handle(c, message)
+	}
+}
+
+// writeLoop gets the channel itself as the hub clears c.send on disconnect
+func (c *client) writeLoop(send <-chan socketEvent) {
+	for event := range send {
+		err := websocket.JSON.Send(c.conn, event)
+
+		if err != nil {
+			break
+		}
+	}
+
+	c.conn.Close()
+}
+
+func (h *hub) handle(c *client, message SocketMessage) {
+	// the task is looked up before taking the lock, every socket would wait on sqlite otherwise
+	taskFound := false
+
+	if taskId, ok := messageTaskID(message); ok {
+		_, err := models.GetTask(taskId)
+		taskFound = err == nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	switch message.Type {
+	case "ping":
+		h.sendTo(c, socketEvent{Type: "pong"})
+	case "join":
+		h.join(c, message.Room, taskFound)
+	case "leave":
+		h.leave(c, message.Room)
+	case "typing":
+		if _, ok := c.rooms[message.Room]; !ok {
+			h.sendTo(c, socketEvent{Type: "error", Message: "Join the room before typing in it."})
+			return
+		}
+
+		h.broadcast(message.Room, c, socketEvent{Type: "typing", Room: message.Room, UserID: c.userId})
+	case "lock":
+		h.lock(c, message.TaskID, taskFound)
+	case "unlock":
+		h.unlock(c, message.TaskID)
+	default:
+		h.sendTo(c, socketEvent{Type: "error", Message: "Unknown message type."})
+	}
+}
+
+// messageTaskID is the task a join or lock message is about
+func messageTaskID(message SocketMessage) (int64, bool) {
+	switch message.Type {
+	case "join":
+		taskId, ok := strings.CutPrefix(message.Room, "task:")
+
+		if !ok || !roomPattern.MatchString(message.Room) {
+			return 0, false
+		}
+
+		id, err := strconv.ParseInt(taskId, 10, 64)
+
+		return id, err == nil
+	case "lock":
+		return message.TaskID, true
+	}
+
+	return 0, false
+}
+
+// join puts the client in the room, taskFound tells if the task of a task room exists
+func (h *hub) join(c *client, room string, taskFound bool) {
+	if !roomPattern.MatchString(room) {
+		h.sendTo(c, socketEvent{Type: "error", Message: "Rooms are named task:<id> or board:<name>."})
+		return
+	}
+
+	if strings.HasPrefix(room, "task:") && !taskFound {
+		h.sendTo(c, socketEvent{Type: "error", Room: room, Message: "No task was found!"})
+		return
+	}
+
+	if _, ok := c.rooms[room]; ok {
+		return
+	}
+
+	wasPresent := slices.Contains(h.roomUsers(room), c.userId)
+
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*client]struct{})
+	}
+
+	h.rooms[room][c] = struct{}{}
+	c.rooms[room] = struct{}{}
+
+	// a second tab of the same user is not a new visitor
+	if !wasPresent {
+		h.broadcast(room, c, socketEvent{Type: "presence.join", Room: room, UserID: c.userId})
+	}
+
+	h.sendTo(c, socketEvent{Type: "presence.state", Room: room, UserIDs: h.roomUsers(room)})
+
+	if taskId, ok := strings.CutPrefix(room, "task:"); ok {
+		id, _ := strconv.ParseInt(taskId, 10, 64)
+
+		if lock, ok := h.locks[id]; ok {
+			h.sendTo(c, socketEvent{Type: "lock.acquired", TaskID: id, UserID: lock.holder.userId, ExpiresAt: &lock.expiresAt})
+		}
+	}
+}
+
+func (h *hub) leave(c *client, room string) {
+	if _, ok := c.rooms[room]; !ok {
+		return
+	}
+
+	delete(c.rooms, room)
+	delete(h.rooms[room], c)
+
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+
+	if !slices.Contains(h.roomUsers(room), c.userId) {
+		h.broadcast(room, nil, socketEvent{Type: "presence.leave", Room: room, UserID: c.userId})
+	}
+}
+
+// lock takes or renews the soft lock of the task, it expires after the lock ttl
+// unless the client sends lock again
+func (h *hub) lock(c *client, taskId int64, taskFound bool) {
+	lock, locked := h.locks[taskId]
+
+	// the janitor only runs every few seconds
+	if locked && time.Now().After(lock.expiresAt) {
+		h.release(taskId, lock)
+		locked = false
+	}
+
+	if locked && lock.holder.userId != c.userId {
+		h.sendTo(c, socketEvent{Type: "lock.denied", TaskID: taskId, UserID: lock.holder.userId, ExpiresAt: &lock.expiresAt})
+		return
+	}
+
+	if !locked && !taskFound {
+		h.sendTo(c, socketEvent{Type: "error", Message: "No task was found!"})
+		return
+	}
+
+	lock = softLock{holder: c, expiresAt: time.Now().UTC().Add(h.lockTTL)}
+	h.locks[taskId] = lock
+
+	event := socketEvent{Type: "lock.acquired", TaskID: taskId, UserID: c.userId, ExpiresAt: &lock.expiresAt}
+
+	h.broadcast(taskRoom(taskId), c, event)
+	h.sendTo(c, event)
+}
+
+func (h *hub) unlock(c *client, taskId int64) {
+	lock, locked := h.locks[taskId]
+
+	if !locked || lock.holder.userId != c.userId {
+		return
+	}
+
+	h.release(taskId, lock)
+}
+
+func (h *hub) release(taskId int64, lock softLock) {
+	delete(h.locks, taskId)
+
+	event := socketEvent{Type: "lock.released", TaskID: taskId, UserID: lock.holder.userId}
+
+	h.broadcast(taskRoom(taskId), lock.holder, event)
+	h.sendTo(lock.holder, event)
+}
+
+func (h *hub) expireLocks() {
+	ticker := time.NewTicker(5 * time.Second)
+
+	for now := range ticker.C {
+		h.mutex.Lock()
+
+		for taskId, lock := range h.locks {
+			if now.After(lock.expiresAt) {
+				h.release(taskId, lock)
+			}
+		}
+
+		h.mutex.Unlock()
+	}
+}
+
+func (h *hub) disconnect(c *client) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for room := range c.rooms {
+		h.leave(c, room)
+	}
+
+	for taskId, lock := range h.locks {
+		if lock.holder == c {
+			delete(h.locks, taskId)
+			h.broadcast(taskRoom(taskId), nil, socketEvent{Type: "lock.released", TaskID: taskId, UserID: c.userId})
+		}
+	}
+
+	close(c.send)
+	c.send = nil
+}
+
+func (h *hub) reply(c *client, event socketEvent) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.sendTo(c, event)
+}
+
+// sendTo never blocks the hub, a client that can't keep up is disconnected
+func (h *hub) sendTo(c *client, event socketEvent) {
+	if c.send == nil {
+		return
+	}
+
+	select {
+	case c.send <- event:
+	default:
+		c.conn.Close()
+	}
+}
+
+// broadcast sends the event to everyone in the room except the sender
+func (h *hub) broadcast(room string, sender *client, event socketEvent) {
+	for member := range h.rooms[room] {
+		if member != sender {
+			h.sendTo(member, event)
+		}
+	}
+}
+
+func (h *hub) roomUsers(room string) []int64 {
+	var userIds []int64
+
+	for member := range h.rooms[room] {
+		if !slices.Contains(userIds, member.userId) {
+			userIds = append(userIds, member.userId)
+		}
+	}
+
+	slices.Sort(userIds)
+
+	return userIds
+}
+
+func taskRoom(taskId int64) string {
+	return "task:" + strconv.FormatInt(taskId, 10)
+}
@@ -0,0 +1,363 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/jobs"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/storage"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+)
+
+var defaultAllowedAttachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+	"text/csv",
+	"application/zip",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+func uploadAttachment(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	_, err = models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	maxSize := utils.GetEnvInt64("ATTACHMENT_MAX_SIZE_MB", 10) << 20
+
+	// leave some room for the multipart boundaries and the other form fields
+	context.Request.Body = http.MaxBytesReader(context.Writer, context.Request.Body, maxSize+1<<20)
+
+	fileHeader, err := context.FormFile("file")
+
+	var maxBytesError *http.MaxBytesError
+
+	if errors.As(err, &maxBytesError) {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message": fmt.Sprintf("File must be at most %d bytes.", maxSize),
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "file is required",
+		})
+		return
+	}
+
+	if fileHeader.Size > maxSize {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message": fmt.Sprintf("File must be at most %d bytes.", maxSize),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not read the uploaded file.",
+		})
+		return
+	}
+
+	defer file.Close()
+
+	// never trust the Content-Type the client sent, sniff the bytes instead
+	detectedType, err := mimetype.DetectReader(file)
+
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not read the uploaded file.",
+		})
+		return
+	}
+
+	allowedTypes := utils.GetEnvList("ATTACHMENT_ALLOWED_TYPES", defaultAllowedAttachmentTypes)
+
+	if !mimetype.EqualsAny(detectedType.String(), allowedTypes...) {
+		context.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"message": fmt.Sprintf("File type %s is not allowed.", detectedType.String()),
+		})
+		return
+	}
+
+	randomName, err := utils.GenerateRandomHex(16)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the attachment.",
+		})
+		return
+	}
+
+	attachment := models.Attachment{
+		TaskID:      *taskId,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: detectedType.String(),
+		Size:        fileHeader.Size,
+		StorageKey:  fmt.Sprintf("tasks/%d/%s%s", *taskId, randomName, detectedType.Extension()),
+		UploadedBy:  context.GetInt64("userId"),
+		CreatedAt:   time.Now(),
+	}
+
+	var body io.Reader = file
+
+	// images are small enough to hold in memory and must not keep their EXIF data (gps location etc.)
+	if attachment.IsImage() {
+		data, err := io.ReadAll(file)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "Could not read the uploaded file.",
+			})
+			return
+		}
+
+		data = utils.StripImageMetadata(data, attachment.ContentType)
+		attachment.Size = int64(len(data))
+		body = bytes.NewReader(data)
+	}
+
+	err = storage.Store.Put(attachment.StorageKey, body, attachment.Size, attachment.ContentType)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the attachment.",
+		})
+		return
+	}
+
+	err = attachment.Save()
+
+	if err != nil {
+		// do not leave an orphan blob behind
+		storage.Store.Delete(attachment.StorageKey)
+
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the attachment.",
+		})
+		return
+	}
+
+	if attachment.IsImage() {
+		jobs.EnqueueThumbnails(attachment.ID)
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Attachment was uploaded successfully!",
+		"data":    attachment,
+	})
+}
+
+func getTaskAttachments(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	attachments, err := models.GetTaskAttachments(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the attachments.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Fetching attachments was successful.",
+		"data":    attachments,
+	})
+}
+
+func downloadAttachment(context *gin.Context) {
+	attachmentId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Attachment id could not be parsed.",
+		})
+		return
+	}
+
+	attachment, err := models.GetAttachment(*attachmentId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No attachment was found!",
+		})
+		return
+	}
+
+	object, err := storage.Store.Open(attachment.StorageKey)
+
+	if errors.Is(err, storage.ErrNotFound) {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No attachment was found!",
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not read the attachment.",
+		})
+		return
+	}
+
+	defer object.Close()
+
+	context.Header("Content-Type", attachment.ContentType)
+	context.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": attachment.FileName,
+	}))
+
+	// ServeContent streams the object and takes care of Range / If-Range headers
+	http.ServeContent(context.Writer, context.Request, attachment.FileName, attachment.CreatedAt, object)
+}
+
+func getAttachmentThumbnail(context *gin.Context) {
+	attachmentId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Attachment id could not be parsed.",
+		})
+		return
+	}
+
+	size, err := strconv.Atoi(context.DefaultQuery("size", "320"))
+
+	if err != nil || !slices.Contains(models.ThumbnailSizes, size) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("size must be one of %v", models.ThumbnailSizes),
+		})
+		return
+	}
+
+	attachment, err := models.GetAttachment(*attachmentId)
+
+	if err != nil || !attachment.IsImage() {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No thumbnail was found!",
+		})
+		return
+	}
+
+	// thumbnails are generated in the background, so they might not exist yet
+	object, err := storage.Store.Open(attachment.ThumbnailKey(size))
+
+	if errors.Is(err, storage.ErrNotFound) && attachment.ThumbnailsPending {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "Thumbnail is not available yet.",
+		})
+		return
+	}
+
+	// images that can't be decoded (webp, svg...) never get one, the original is all there is
+	if errors.Is(err, storage.ErrNotFound) {
+		context.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("/attachments/%d", attachment.ID))
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not read the thumbnail.",
+		})
+		return
+	}
+
+	defer object.Close()
+
+	context.Header("Content-Type", "image/jpeg")
+	context.Header("Cache-Control", "private, max-age=86400")
+
+	http.ServeContent(context.Writer, context.Request, "", attachment.CreatedAt, object)
+}
+
+func deleteAttachment(context *gin.Context) {
+	attachmentId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Attachment id could not be parsed.",
+		})
+		return
+	}
+
+	attachment, err := models.GetAttachment(*attachmentId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No attachment was found!",
+		})
+		return
+	}
+
+	err = storage.Store.Delete(attachment.StorageKey)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the attachment.",
+		})
+		return
+	}
+
+	if attachment.IsImage() {
+		for _, size := range models.ThumbnailSizes {
+			storage.Store.Delete(attachment.ThumbnailKey(size))
+		}
+	}
+
+	err = attachment.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the attachment.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Attachment was deleted successfully!",
+	})
+}
@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// requestActor is the logged in user making the change, domain events carry it to the audit trail
+func requestActor(context *gin.Context) models.Actor {
+	return models.Actor{
+		UserID:    context.GetInt64("userId"),
+		RequestID: context.GetString("requestId"),
+	}
+}
+
+func getTaskActivity(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	limit, offset := parsePagination(context)
+
+	events, err := models.GetAuditEvents(models.AuditFilter{
+		EntityType: "task",
+		EntityID:   *taskId,
+		Limit:      limit,
+		Offset:     offset,
+	})
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the task activity.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    events,
+	})
+}
+
+// getAuditEvents: /audit?entity_type=task&entity_id=1&actor_id=2&action=update&from=2025-01-01&to=2025-02-01
+func getAuditEvents(context *gin.Context) {
+	limit, offset := parsePagination(context)
+
+	filter := models.AuditFilter{
+		EntityType: context.Query("entity_type"),
+		Action:     models.AuditAction(context.Query("action")),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	if value := context.Query("entity_id"); value != "" {
+		entityId, err := utils.ConvertStringToInt(value)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "entity_id could not be parsed.",
+			})
+			return
+		}
+
+		filter.EntityID = *entityId
+	}
+
+	if value := context.Query("actor_id"); value != "" {
+		actorId, err := utils.ConvertStringToInt(value)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "actor_id could not be parsed.",
+			})
+			return
+		}
+
+		filter.ActorID = *actorId
+	}
+
+	var err error
+
+	if value := context.Query("from"); value != "" {
+		filter.From, err = time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "from must be a date like 2006-01-02.",
+			})
+			return
+		}
+	}
+
+	if value := context.Query("to"); value != "" {
+		filter.To, err = time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "to must be a date like 2006-01-02.",
+			})
+			return
+		}
+	}
+
+	events, err := models.GetAuditEvents(filter)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the audit events.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    events,
+	})
+}
+
+// parsePagination reads ?limit=&offset=, limit is capped at 100
+func parsePagination(context *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(context.DefaultQuery("limit", "50"))
+
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	offset, err := strconv.Atoi(context.DefaultQuery("offset", "0"))
+
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return min(limit, 100), offset
+}
@@ -0,0 +1,85 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// getBoard takes the same filters as the task list
+func getBoard(context *gin.Context) {
+	filter, err := parseTaskFilter(context.Request.URL.Query())
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	columns, err := models.GetBoard(*filter)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the board.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    columns,
+	})
+}
+
+func moveTask(context *gin.Context) {
+	var move models.BoardMove
+
+	err := context.ShouldBindJSON(&move)
+
+	if utils.CheckValidationErrors(context, err, move) {
+		return
+	}
+
+	existingTask, err := models.GetTask(move.TaskID)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	if !checkTaskStatus(context, move.Status, existingTask.Status) {
+		return
+	}
+
+	task, err := models.MoveTask(move, requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondTaskConflict(context, move.TaskID)
+		return
+	}
+
+	if errors.Is(err, models.ErrBoardNeighbor) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not move the task.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Task was moved successfully!",
+		"data":    task,
+	})
+}
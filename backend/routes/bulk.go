@@ -0,0 +1,329 @@
+package routes
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// the tasks are picked by ids or by a filter written like the query string of
+// GET /tasks e.g. "status=todo&labels_any=1,2"
+type bulkTaskRequest struct {
+	IDs     []int64         `json:"ids"`
+	Filter  string          `json:"filter"`
+	Action  string          `json:"action" binding:"required,oneof=update delete"`
+	Changes bulkTaskChanges `json:"changes"`
+	DryRun  bool            `json:"dry_run"`
+}
+
+// only the fields that are sent are changed, a category_id of 0 takes the category away
+type bulkTaskChanges struct {
+	Status          *models.Status   `json:"status"`
+	Priority        *models.Priority `json:"priority"`
+	CategoryID      *int64           `json:"category_id"`
+	AddAssignees    []int64          `json:"add_assignees"`
+	RemoveAssignees []int64          `json:"remove_assignees"`
+}
+
+type bulkTaskResult struct {
+	ID    int64  `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func (changes bulkTaskChanges) isEmpty() bool {
+	return changes.Status == nil && changes.Priority == nil && changes.CategoryID == nil && len(changes.AddAssignees) == 0 && len(changes.RemoveAssignees) == 0
+}
+
+// bulkTasks updates or deletes many tasks in one transaction. Every task is checked first and
+// when one of them can't be changed nothing is saved, the results tell which one and why
+func bulkTasks(context *gin.Context) {
+	var request bulkTaskRequest
+
+	err := context.ShouldBindJSON(&request)
+
+	if utils.CheckValidationErrors(context, err, request) || !checkBulkTaskRequest(context, request) {
+		return
+	}
+
+	tasks, results, ok := loadBulkTasks(context, request)
+
+	if !ok {
+		return
+	}
+
+	var changes []models.TaskChange
+
+	if request.Action == "update" {
+		now := time.Now()
+
+		for _, task := range tasks {
+			after, problem, err := applyBulkChanges(task, request.Changes)
+
+			if err != nil {
+				context.JSON(http.StatusInternalServerError, gin.H{
+					"message": "Could not check the tasks.",
+				})
+				return
+			}
+
+			after.UpdatedAt = now
+
+			results = append(results, bulkTaskResult{ID: task.ID, OK: problem == "", Error: problem})
+			changes = append(changes, models.TaskChange{Before: task, After: *after})
+		}
+	} else {
+		for _, task := range tasks {
+			results = append(results, bulkTaskResult{ID: task.ID, OK: true})
+		}
+	}
+
+	slices.SortFunc(results, func(a, b bulkTaskResult) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	if slices.ContainsFunc(results, func(result bulkTaskResult) bool { return !result.OK }) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Some of the tasks can't be changed, nothing was saved.",
+			"data":    results,
+		})
+		return
+	}
+
+	if request.DryRun {
+		context.JSON(http.StatusOK, gin.H{
+			"message": "Dry run, nothing was saved.",
+			"data":    results,
+		})
+		return
+	}
+
+	if request.Action == "update" {
+		err = models.UpdateTasks(changes, requestActor(context))
+	} else {
+		err = models.DeleteTasks(tasks, requestActor(context))
+	}
+
+	var conflictError models.TaskConflictError
+
+	if errors.As(err, &conflictError) {
+		context.JSON(http.StatusPreconditionFailed, gin.H{
+			"message": fmt.Sprintf("Task %d was changed by someone else in the meantime, nothing was saved.", conflictError.TaskID),
+			"task_id": conflictError.TaskID,
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not change the tasks, nothing was saved.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Tasks were changed successfully!",
+		"data":    results,
+	})
+}
+
+// checkBulkTaskRequest checks what is the same for every task of the request
+func checkBulkTaskRequest(context *gin.Context, request bulkTaskRequest) bool {
+	errorsOutput := make(map[string]string)
+
+	if (len(request.IDs) == 0) == (request.Filter == "") {
+		errorsOutput["ids"] = "send either ids or filter"
+	}
+
+	if request.Action == "update" && request.Changes.isEmpty() {
+		errorsOutput["changes"] = "changes are required to update tasks"
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return false
+	}
+
+	if request.Action != "update" {
+		return true
+	}
+
+	changes := request.Changes
+
+	if changes.Status != nil && !checkTaskStatus(context, *changes.Status, "") {
+		return false
+	}
+
+	if changes.Priority != nil && !checkTaskPriority(context, *changes.Priority) {
+		return false
+	}
+
+	if !checkTaskAssignees(context, changes.AddAssignees, nil) {
+		return false
+	}
+
+	if changes.CategoryID != nil && *changes.CategoryID != 0 {
+		_, err := models.GetCategory(*changes.CategoryID)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "Request validation errors.",
+				"errors": gin.H{
+					"category_id": "category_id is not a category",
+				},
+			})
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadBulkTasks finds the tasks of the request, the ids that were not found are failed results
+func loadBulkTasks(context *gin.Context, request bulkTaskRequest) ([]models.Task, []bulkTaskResult, bool) {
+	var tasks []models.Task
+	var results []bulkTaskResult
+
+	if request.Filter != "" {
+		query, err := url.ParseQuery(request.Filter)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "filter could not be parsed.",
+			})
+			return nil, nil, false
+		}
+
+		filter, err := parseTaskFilter(query)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+			})
+			return nil, nil, false
+		}
+
+		// the filter may match every task, it is counted before anything is loaded.
+		// The sla is only known once the tasks are loaded, so that filter is checked after
+		count, err := models.CountTasks(*filter)
+
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not count the tasks.",
+			})
+			return nil, nil, false
+		}
+
+		if count > models.MaxBulkTasks && !filter.SLABreached {
+			respondTooManyBulkTasks(context)
+			return nil, nil, false
+		}
+
+		tasks, err = models.GetTasks(*filter)
+
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not get the tasks.",
+			})
+			return nil, nil, false
+		}
+	} else {
+		slices.Sort(request.IDs)
+		taskIds := slices.Compact(request.IDs)
+
+		if len(taskIds) > models.MaxBulkTasks {
+			respondTooManyBulkTasks(context)
+			return nil, nil, false
+		}
+
+		for _, taskId := range taskIds {
+			task, err := models.GetTask(taskId)
+
+			if err != nil {
+				results = append(results, bulkTaskResult{ID: taskId, Error: "No task was found!"})
+				continue
+			}
+
+			tasks = append(tasks, *task)
+		}
+	}
+
+	if len(tasks) > models.MaxBulkTasks {
+		respondTooManyBulkTasks(context)
+		return nil, nil, false
+	}
+
+	return tasks, results, true
+}
+
+func respondTooManyBulkTasks(context *gin.Context) {
+	context.JSON(http.StatusBadRequest, gin.H{
+		"message": fmt.Sprintf("At most %d tasks can be changed at once.", models.MaxBulkTasks),
+	})
+}
+
+// applyBulkChanges returns the task with the changes and why it can't be saved, if it can't
+func applyBulkChanges(task models.Task, changes bulkTaskChanges) (*models.Task, string, error) {
+	after := task
+	after.AssigneesIDs = slices.Clone(task.AssigneesIDs)
+
+	if changes.Status != nil {
+		after.Status = *changes.Status
+	}
+
+	if changes.Priority != nil {
+		after.Priority = *changes.Priority
+	}
+
+	if changes.CategoryID != nil {
+		after.CategoryID = *changes.CategoryID
+	}
+
+	after.AssigneesIDs = append(after.AssigneesIDs, changes.AddAssignees...)
+	after.AssigneesIDs = slices.DeleteFunc(after.AssigneesIDs, func(userId int64) bool {
+		return slices.Contains(changes.RemoveAssignees, userId)
+	})
+
+	problem, err := taskStatusProblem(after.Status, task.Status)
+
+	if err != nil || problem != "" {
+		return &after, problem, err
+	}
+
+	// the values of the fields the new category does not have are dropped,
+	// the ones it has may still be missing a required field
+	if after.CategoryID != task.CategoryID {
+		values, err := models.ApplicableCustomValues(after.CategoryID, after.CustomFields)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		values, errorsOutput, err := models.ValidateCustomValues(after.CategoryID, values)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if len(errorsOutput) > 0 {
+			return &after, strings.Join(slices.Sorted(maps.Values(errorsOutput)), ", "), nil
+		}
+
+		after.CustomFields = values
+	}
+
+	return &after, "", nil
+}
@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/abolfazlcodes/task-dashboard/backend/models"
@@ -19,7 +20,7 @@ func createCategory(context *gin.Context) {
 
 	// save the category in db
 
-	err = category.Save()
+	err = category.Save(requestActor(context))
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
@@ -45,7 +46,7 @@ func updateCategory(context *gin.Context) {
 	}
 
 	// get category if exists
-	_, err = models.GetCategory(*categoryId)
+	existingCategory, err := models.GetCategory(*categoryId)
 
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{
@@ -55,6 +56,10 @@ func updateCategory(context *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(context, existingCategory.Version, existingCategory) {
+		return
+	}
+
 	var updatedCategory models.Category
 
 	err = context.ShouldBindJSON(&updatedCategory)
@@ -64,8 +69,14 @@ func updateCategory(context *gin.Context) {
 	}
 
 	updatedCategory.ID = *categoryId
+	updatedCategory.Version = existingCategory.Version
 
-	err = updatedCategory.Update()
+	err = updatedCategory.Update(requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondCategoryConflict(context, *categoryId)
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
@@ -74,6 +85,8 @@ func updateCategory(context *gin.Context) {
 		return
 	}
 
+	setETag(context, updatedCategory.Version)
+
 	context.JSON(http.StatusOK, gin.H{
 		"message": "Category was updated successfully!",
 	})
@@ -101,8 +114,17 @@ func deleteCategory(context *gin.Context) {
 		return
 	}
 
+	if !checkIfMatch(context, category.Version, category) {
+		return
+	}
+
 	// delete the category
-	err = category.Delete()
+	err = category.Delete(requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondCategoryConflict(context, category.ID)
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{
@@ -116,6 +138,33 @@ func deleteCategory(context *gin.Context) {
 	})
 }
 
+func getCategory(context *gin.Context) {
+	categoryId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Category id could not be parsed.",
+		})
+		return
+	}
+
+	category, err := models.GetCategory(*categoryId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No category was found!",
+		})
+		return
+	}
+
+	setETag(context, category.Version)
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    category,
+	})
+}
+
 func getCategories(context *gin.Context) {
 	categories, err := models.GetAllCategories()
 
@@ -0,0 +1,192 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type reorderChecklistRequest struct {
+	ItemIDs []int64 `json:"item_ids" binding:"required"`
+}
+
+func getTaskChecklist(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	checklist, err := models.GetTaskChecklist(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the checklist.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    checklist,
+	})
+}
+
+func addChecklistItem(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	_, err = models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	var item models.ChecklistItem
+
+	err = context.ShouldBindJSON(&item)
+
+	if utils.CheckValidationErrors(context, err, item) {
+		return
+	}
+
+	item.TaskID = *taskId
+
+	err = item.Save()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not add the checklist item.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Checklist item was added successfully!",
+		"data":    item,
+	})
+}
+
+func reorderChecklist(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	var request reorderChecklistRequest
+
+	err = context.ShouldBindJSON(&request)
+
+	if utils.CheckValidationErrors(context, err, request) {
+		return
+	}
+
+	err = models.ReorderChecklist(*taskId, request.ItemIDs)
+
+	if errors.Is(err, models.ErrChecklistOrderMismatch) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors": gin.H{
+				"item_ids": err.Error(),
+			},
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not reorder the checklist.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Checklist was reordered successfully!",
+	})
+}
+
+func toggleChecklistItem(context *gin.Context) {
+	itemId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Checklist item id could not be parsed.",
+		})
+		return
+	}
+
+	item, err := models.GetChecklistItem(*itemId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No checklist item was found!",
+		})
+		return
+	}
+
+	err = item.Toggle(context.GetInt64("userId"))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the checklist item.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item was updated successfully!",
+		"data":    item,
+	})
+}
+
+func deleteChecklistItem(context *gin.Context) {
+	itemId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Checklist item id could not be parsed.",
+		})
+		return
+	}
+
+	item, err := models.GetChecklistItem(*itemId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No checklist item was found!",
+		})
+		return
+	}
+
+	err = item.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the checklist item.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item was deleted successfully!",
+	})
+}
@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func createComment(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	task, err := models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	var comment models.Comment
+
+	err = context.ShouldBindJSON(&comment)
+
+	if utils.CheckValidationErrors(context, err, comment) {
+		return
+	}
+
+	comment.TaskID = task.ID
+	comment.UserID = context.GetInt64("userId")
+
+	err = comment.Save(requestActor(context))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the comment.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Comment was created successfully!",
+		"data":    comment,
+	})
+}
+
+func getTaskComments(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	comments, err := models.GetTaskComments(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the comments.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    comments,
+	})
+}
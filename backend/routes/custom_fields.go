@@ -0,0 +1,216 @@
+package routes
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getCustomFields(context *gin.Context) {
+	fields, err := models.GetCustomFields()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the custom fields.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    fields,
+	})
+}
+
+func createCustomField(context *gin.Context) {
+	var field models.CustomField
+
+	err := context.ShouldBindJSON(&field)
+
+	if utils.CheckValidationErrors(context, err, field) || !checkCustomField(context, field) {
+		return
+	}
+
+	err = field.Save()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not create the custom field, the key may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Custom field was created successfully!",
+		"data":    field,
+	})
+}
+
+// updateCustomField can't change the key and the type, the values on the tasks depend on them.
+// The body is bound over the stored field so they can be left out
+func updateCustomField(context *gin.Context) {
+	fieldId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Custom field id could not be parsed.",
+		})
+		return
+	}
+
+	field, err := models.GetCustomField(*fieldId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No custom field was found!",
+		})
+		return
+	}
+
+	key, fieldType := field.Key, field.Type
+
+	err = context.ShouldBindJSON(field)
+
+	if utils.CheckValidationErrors(context, err, *field) {
+		return
+	}
+
+	errorsOutput := make(map[string]string)
+
+	if field.Key != key {
+		errorsOutput["key"] = "key can't be changed, create a new field instead"
+	}
+
+	if field.Type != fieldType {
+		errorsOutput["type"] = "type can't be changed, create a new field instead"
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return
+	}
+
+	field.ID = *fieldId
+
+	if !checkCustomField(context, *field) {
+		return
+	}
+
+	err = field.Update()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the custom field.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Custom field was updated successfully!",
+		"data":    field,
+	})
+}
+
+func deleteCustomField(context *gin.Context) {
+	fieldId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Custom field id could not be parsed.",
+		})
+		return
+	}
+
+	field, err := models.GetCustomField(*fieldId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No custom field was found!",
+		})
+		return
+	}
+
+	err = field.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the custom field.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Custom field was deleted successfully!",
+	})
+}
+
+func checkCustomField(context *gin.Context, field models.CustomField) bool {
+	errorsOutput := make(map[string]string)
+
+	if !field.ValidKey() {
+		errorsOutput["key"] = "key can only have lowercase letters, digits and underscores and has to start with a letter"
+	}
+
+	if field.IsSelect() {
+		if len(field.Options) == 0 {
+			errorsOutput["options"] = "options are required for select fields"
+		}
+
+		for i, option := range field.Options {
+			if option == "" || slices.Contains(field.Options[:i], option) {
+				errorsOutput["options"] = "options have to be unique and not empty"
+			}
+		}
+	} else if len(field.Options) > 0 {
+		errorsOutput["options"] = "only select fields have options"
+	}
+
+	if field.CategoryID != 0 {
+		_, err := models.GetCategory(field.CategoryID)
+
+		if err != nil {
+			errorsOutput["category_id"] = "category_id is not a category"
+		}
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return false
+	}
+
+	return true
+}
+
+// checkTaskCustomFields validates the custom field values of the task and puts
+// them in the form they are stored in
+func checkTaskCustomFields(context *gin.Context, task *models.Task) bool {
+	values, errorsOutput, err := models.ValidateCustomValues(task.CategoryID, task.CustomFields)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the custom fields.",
+		})
+		return false
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return false
+	}
+
+	task.CustomFields = values
+
+	return true
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getDigestSettings(context *gin.Context) {
+	settings, err := models.GetDigestSettings(context.GetInt64("userId"))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the digest settings.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    settings,
+	})
+}
+
+func updateDigestSettings(context *gin.Context) {
+	var settings models.DigestSettings
+
+	err := context.ShouldBindJSON(&settings)
+
+	if utils.CheckValidationErrors(context, err, settings) {
+		return
+	}
+
+	settings.UserID = context.GetInt64("userId")
+
+	err = settings.Save()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the digest settings.",
+		})
+		return
+	}
+
+	getDigestSettings(context)
+}
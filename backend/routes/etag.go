@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// the ETag of a task or a category is its version e.g. "3"
+func etag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+func setETag(context *gin.Context, version int64) {
+	context.Header("ETag", etag(version))
+}
+
+// checkIfMatch lets a write through when If-Match has the current ETag or is *. Without
+// the header the write goes through too, unless IF_MATCH_REQUIRED is set
+func checkIfMatch(context *gin.Context, version int64, current any) bool {
+	ifMatch := context.GetHeader("If-Match")
+
+	if ifMatch == "" {
+		if !utils.GetEnvBool("IF_MATCH_REQUIRED", false) {
+			return true
+		}
+
+		context.JSON(http.StatusPreconditionRequired, gin.H{
+			"message": "If-Match header is required, send the ETag you got when reading it.",
+		})
+		return false
+	}
+
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+
+	respondVersionConflict(context, version, current)
+
+	return false
+}
+
+// respondVersionConflict sends the current representation so the client can merge and retry
+func respondVersionConflict(context *gin.Context, version int64, current any) {
+	setETag(context, version)
+
+	context.JSON(http.StatusPreconditionFailed, gin.H{
+		"message": "It was changed by someone else in the meantime, this is the current version.",
+		"data":    current,
+	})
+}
+
+// respondTaskConflict is for writes that lost the race after the If-Match check
+func respondTaskConflict(context *gin.Context, taskId int64) {
+	task, err := models.GetTask(taskId)
+
+	if err != nil {
+		context.JSON(http.StatusPreconditionFailed, gin.H{
+			"message": "It was changed by someone else in the meantime.",
+		})
+		return
+	}
+
+	respondVersionConflict(context, task.Version, task)
+}
+
+func respondCategoryConflict(context *gin.Context, categoryId int64) {
+	category, err := models.GetCategory(categoryId)
+
+	if err != nil {
+		context.JSON(http.StatusPreconditionFailed, gin.H{
+			"message": "It was changed by someone else in the meantime.",
+		})
+		return
+	}
+
+	respondVersionConflict(context, category.Version, category)
+}
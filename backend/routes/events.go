@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/realtime"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// streamEvents is the server-sent events stream, browsers send Last-Event-ID on their own when
+// they reconnect, a "reset" event tells the client it missed too much and has to reload
+func streamEvents(context *gin.Context) {
+	userId := context.GetInt64("userId")
+
+	lastEventId, _ := strconv.ParseInt(context.GetHeader("Last-Event-ID"), 10, 64)
+
+	missed, resumed, subscription := realtime.Subscribe(userId, lastEventId)
+	defer realtime.Unsubscribe(subscription)
+
+	heartbeat := time.NewTicker(time.Duration(utils.GetEnvInt64("EVENTS_HEARTBEAT_SECONDS", 15)) * time.Second)
+	defer heartbeat.Stop()
+
+	context.Header("Content-Type", "text/event-stream")
+	context.Header("Cache-Control", "no-cache")
+	context.Header("Connection", "keep-alive")
+	// nginx would otherwise buffer the whole stream
+	context.Header("X-Accel-Buffering", "no")
+
+	if !resumed {
+		context.Render(-1, sse.Event{Event: "reset", Data: gin.H{"message": "Missed events are no longer available, reload the data."}})
+	}
+
+	for _, event := range missed {
+		renderEvent(context, event)
+	}
+
+	context.Writer.Flush()
+
+	context.Stream(func(writer io.Writer) bool {
+		select {
+		case event, ok := <-subscription.Events:
+			// the broker drops clients that fall behind, they reconnect and catch up
+			if !ok {
+				return false
+			}
+
+			renderEvent(context, event)
+		case <-heartbeat.C:
+			_, err := io.WriteString(writer, ": heartbeat\n\n")
+
+			if err != nil {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+func renderEvent(context *gin.Context, event realtime.Event) {
+	context.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: event.Type,
+		Data: gin.H{
+			"type":       event.Type,
+			"data":       event.Data,
+			"created_at": event.CreatedAt,
+		},
+	})
+}
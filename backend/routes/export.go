@@ -0,0 +1,383 @@
+package routes
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// the tasks are read and written this many at a time
+const exportBatchSize = 200
+
+// the columns before the custom fields, named like the fields of the import
+var exportColumns = []string{"id", "title", "description", "status", "priority", "category", "assignees", "labels", "due_date", "estimate_minutes", "created_at", "updated_at", "resolved_at", "archived_at"}
+
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"xlsx":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// taskExportWriter writes the task rows of one export format
+type taskExportWriter interface {
+	WriteRow(cells []any) error
+	Flush() error
+	Close() error
+}
+
+// exportTasks downloads the tasks of GET /tasks with the same filters, ?format= is csv
+// (the default), ndjson or xlsx. Categories, assignees and labels are given by name and every
+// custom field gets a custom_fields.<key> column. The tasks are streamed in batches
+func exportTasks(context *gin.Context) {
+	format := context.DefaultQuery("format", "csv")
+
+	contentType, ok := exportContentTypes[format]
+
+	if !ok {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "format can only be csv, ndjson or xlsx.",
+		})
+		return
+	}
+
+	filter, err := parseTaskFilter(context.Request.URL.Query())
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	names, err := loadExportNames()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the tasks.",
+		})
+		return
+	}
+
+	columns := append([]string{}, exportColumns...)
+
+	for _, field := range names.customFields {
+		columns = append(columns, "custom_fields."+field.Key)
+	}
+
+	context.Header("Content-Type", contentType)
+	context.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": fmt.Sprintf("tasks-%s.%s", time.Now().Format("2006-01-02"), format),
+	}))
+	context.Status(http.StatusOK)
+
+	// the status is sent with the first bytes, from here on errors can only be logged
+	writer, err := newTaskExportWriter(format, context.Writer, columns)
+
+	if err == nil {
+		err = models.EachTask(*filter, exportBatchSize, func(tasks []models.Task) error {
+			for _, task := range tasks {
+				err := writer.WriteRow(names.row(task))
+
+				if err != nil {
+					return err
+				}
+			}
+
+			err := writer.Flush()
+
+			if err != nil {
+				return err
+			}
+
+			context.Writer.Flush()
+
+			return nil
+		})
+	}
+
+	if err == nil {
+		err = writer.Close()
+	}
+
+	if err != nil {
+		log.Printf("could not export the tasks: %v", err)
+	}
+}
+
+// exportNames resolves the ids of the tasks to the names that are exported
+type exportNames struct {
+	categories   map[int64]string
+	users        map[int64]string
+	labels       map[int64]string
+	customFields []models.CustomField
+}
+
+func loadExportNames() (*exportNames, error) {
+	names := exportNames{categories: make(map[int64]string), labels: make(map[int64]string)}
+
+	categories, err := models.GetAllCategories()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// tasks keep the category while it is in the trash
+	deletedCategories, err := models.GetDeletedCategories(time.Time{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, category := range append(categories, deletedCategories...) {
+		names.categories[category.ID] = category.Title
+	}
+
+	names.users, err = models.GetUserNames()
+
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := models.GetAllLabels()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, label := range labels {
+		names.labels[label.ID] = label.Name
+	}
+
+	names.customFields, err = models.GetCustomFields()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &names, nil
+}
+
+// row gives the cells of a task in the order of the columns, lists are []string
+// and missing values nil
+func (names exportNames) row(task models.Task) []any {
+	var category any
+
+	if title, ok := names.categories[task.CategoryID]; ok {
+		category = title
+	}
+
+	assignees := []string{}
+
+	for _, userId := range task.AssigneesIDs {
+		assignees = append(assignees, names.users[userId])
+	}
+
+	labels := []string{}
+
+	for _, labelId := range task.LabelIDs {
+		labels = append(labels, names.labels[labelId])
+	}
+
+	row := []any{
+		task.ID,
+		task.Title,
+		task.Description,
+		string(task.Status),
+		string(task.Priority),
+		category,
+		assignees,
+		labels,
+		exportTime(&task.DueDate),
+		task.EstimateMinutes,
+		exportTime(&task.CreatedAt),
+		exportTime(&task.UpdatedAt),
+		exportTime(task.ResolvedAt),
+		exportTime(task.ArchivedAt),
+	}
+
+	for _, field := range names.customFields {
+		row = append(row, names.customValue(field, task.CustomFields[field.Key]))
+	}
+
+	return row
+}
+
+func (names exportNames) customValue(field models.CustomField, value any) any {
+	switch value := value.(type) {
+	case float64:
+		if field.Type == models.CustomFieldUser {
+			return names.users[int64(value)]
+		}
+
+		return value
+	case []any:
+		options := []string{}
+
+		for _, option := range value {
+			options = append(options, fmt.Sprint(option))
+		}
+
+		return options
+	}
+
+	return value
+}
+
+func exportTime(value *time.Time) any {
+	if value == nil || value.IsZero() {
+		return nil
+	}
+
+	return value.UTC().Format(time.RFC3339)
+}
+
+// exportCellText gives a cell the text it has in a csv or xlsx file
+func exportCellText(cell any) any {
+	switch cell := cell.(type) {
+	case []string:
+		return strings.Join(cell, ", ")
+	case float64:
+		return strconv.FormatFloat(cell, 'f', -1, 64)
+	}
+
+	return cell
+}
+
+// newTaskExportWriter starts the file, csv and xlsx files begin with a header row of the columns
+func newTaskExportWriter(format string, w io.Writer, columns []string) (taskExportWriter, error) {
+	var writer taskExportWriter
+
+	switch format {
+	case "ndjson":
+		return ndjsonExportWriter{encoder: json.NewEncoder(w), columns: columns}, nil
+	case "xlsx":
+		xlsx, err := utils.NewXLSXWriter(w)
+
+		if err != nil {
+			return nil, err
+		}
+
+		writer = xlsxExportWriter{xlsx}
+	default:
+		writer = csvExportWriter{csv.NewWriter(w)}
+	}
+
+	header := make([]any, len(columns))
+
+	for i, column := range columns {
+		header[i] = column
+	}
+
+	return writer, writer.WriteRow(header)
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (writer csvExportWriter) WriteRow(cells []any) error {
+	record := make([]string, len(cells))
+
+	for i, cell := range cells {
+		if _, ok := cell.(float64); ok {
+			record[i] = fmt.Sprint(exportCellText(cell))
+		} else if cell != nil {
+			record[i] = quoteCSVFormula(fmt.Sprint(exportCellText(cell)))
+		}
+	}
+
+	return writer.writer.Write(record)
+}
+
+// spreadsheets run the cells starting with these as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// quoteCSVFormula puts a quote in front of the text a spreadsheet would run, whoever opens
+// the export must not run what was typed into a task. The import takes the quote off again
+func quoteCSVFormula(text string) string {
+	if text != "" && strings.ContainsRune(csvFormulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+
+	return text
+}
+
+func unquoteCSVFormula(text string) string {
+	if len(text) > 1 && text[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(text[1])) {
+		return text[1:]
+	}
+
+	return text
+}
+
+func (writer csvExportWriter) Flush() error {
+	writer.writer.Flush()
+
+	return writer.writer.Error()
+}
+
+func (writer csvExportWriter) Close() error {
+	return writer.Flush()
+}
+
+type xlsxExportWriter struct {
+	writer *utils.XLSXWriter
+}
+
+func (writer xlsxExportWriter) WriteRow(cells []any) error {
+	texts := make([]any, len(cells))
+
+	for i, cell := range cells {
+		// numbers stay numbers so they can be summed in the sheet
+		if _, ok := cell.(float64); ok {
+			texts[i] = cell
+		} else {
+			texts[i] = exportCellText(cell)
+		}
+	}
+
+	return writer.writer.WriteRow(texts)
+}
+
+func (writer xlsxExportWriter) Flush() error {
+	return writer.writer.Flush()
+}
+
+func (writer xlsxExportWriter) Close() error {
+	return writer.writer.Close()
+}
+
+// ndjsonExportWriter writes a json object per task keyed by the columns
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+	columns []string
+}
+
+func (writer ndjsonExportWriter) WriteRow(cells []any) error {
+	object := make(map[string]any, len(cells))
+
+	for i, cell := range cells {
+		object[writer.columns[i]] = cell
+	}
+
+	return writer.encoder.Encode(object)
+}
+
+func (writer ndjsonExportWriter) Flush() error {
+	return nil
+}
+
+func (writer ndjsonExportWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package routes
+
+import "testing"
+
+func TestQuoteCSVFormula(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"=HYPERLINK(\"x\")", "'=HYPERLINK(\"x\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tindented", "'\tindented"},
+		{"\rcarriage", "'\rcarriage"},
+		{"a=b", "a=b"},
+		{"'quoted", "'quoted"},
+	}
+
+	for _, test := range tests {
+		got := quoteCSVFormula(test.text)
+
+		if got != test.want {
+			t.Errorf("quoteCSVFormula(%q) = %q, want %q", test.text, got, test.want)
+		}
+
+		if back := unquoteCSVFormula(got); back != test.text {
+			t.Errorf("unquoteCSVFormula(%q) = %q, want %q", got, back, test.text)
+		}
+	}
+}
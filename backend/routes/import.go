@@ -0,0 +1,670 @@
+package routes
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// the most rows one import can have and the biggest file it takes
+const (
+	maxImportRows = 1000
+	maxImportSize = 5 << 20
+)
+
+// the task fields a column can be mapped to, custom fields are mapped as custom_fields.<key>
+var importFields = []string{"title", "description", "priority", "status", "due_date", "category", "assignees", "estimate_minutes"}
+
+type importRowResult struct {
+	Row    int               `json:"row"`
+	OK     bool              `json:"ok"`
+	TaskID int64             `json:"task_id,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// importTasks creates tasks from a spreadsheet, it takes a multipart form with
+//   - file: a .csv with a header row or a .json array of objects
+//   - mapping: optional json object from task field to column e.g. {"title": "Summary", "assignees": "Owner"},
+//     without it the columns named like the fields are used, see previewTaskImport
+//   - strict: "true" imports nothing when one of the rows is not valid
+//
+// categories are found by title and assignees by email or username, separated by , or ;
+func importTasks(context *gin.Context) {
+	columns, rows, ok := readImportFile(context)
+
+	if !ok {
+		return
+	}
+
+	customFields, err := models.GetCustomFields()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the custom fields.",
+		})
+		return
+	}
+
+	mapping, ok := parseImportMapping(context, columns, customFields)
+
+	if !ok {
+		return
+	}
+
+	strict := context.PostForm("strict") == "true"
+
+	resolver := newImportResolver()
+
+	var tasks []models.Task
+	var results []importRowResult
+
+	for i, row := range rows {
+		task, errorsOutput, err := resolver.buildTask(row, mapping, customFields)
+
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not check the rows.",
+			})
+			return
+		}
+
+		results = append(results, importRowResult{Row: i + 1, OK: len(errorsOutput) == 0, Errors: errorsOutput})
+
+		if len(errorsOutput) == 0 {
+			tasks = append(tasks, *task)
+		}
+	}
+
+	failed := len(rows) - len(tasks)
+
+	if len(tasks) == 0 || (strict && failed > 0) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Some of the rows are not valid, nothing was imported.",
+			"data":    results,
+		})
+		return
+	}
+
+	err = models.CreateTasks(tasks, requestActor(context))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not import the tasks, nothing was imported.",
+		})
+		return
+	}
+
+	// the saved tasks are in the order of their rows
+	taskIndex := 0
+
+	for i := range results {
+		if results[i].OK {
+			results[i].TaskID = tasks[taskIndex].ID
+			taskIndex++
+		}
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("%d of %d tasks were imported.", len(tasks), len(rows)),
+		"imported": len(tasks),
+		"failed":   failed,
+		"data":     results,
+	})
+}
+
+// previewTaskImport is the mapping step, it returns the columns of the file, the mapping
+// that would be used without one and the first rows
+func previewTaskImport(context *gin.Context) {
+	columns, rows, ok := readImportFile(context)
+
+	if !ok {
+		return
+	}
+
+	customFields, err := models.GetCustomFields()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the custom fields.",
+		})
+		return
+	}
+
+	fields := slices.Clone(importFields)
+
+	for _, field := range customFields {
+		fields = append(fields, "custom_fields."+field.Key)
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data": gin.H{
+			"columns": columns,
+			"fields":  fields,
+			"mapping": suggestImportMapping(columns, customFields),
+			"rows":    rows[:min(len(rows), 5)],
+			"total":   len(rows),
+		},
+	})
+}
+
+// readImportFile reads the uploaded file into its columns and its rows by column
+func readImportFile(context *gin.Context) ([]string, []map[string]string, bool) {
+	context.Request.Body = http.MaxBytesReader(context.Writer, context.Request.Body, maxImportSize+1<<20)
+
+	fileHeader, err := context.FormFile("file")
+
+	var maxBytesError *http.MaxBytesError
+
+	if errors.As(err, &maxBytesError) || (err == nil && fileHeader.Size > maxImportSize) {
+		context.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"message": fmt.Sprintf("File must be at most %d bytes.", maxImportSize),
+		})
+		return nil, nil, false
+	}
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "file is required",
+		})
+		return nil, nil, false
+	}
+
+	format := context.PostForm("format")
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	file, err := fileHeader.Open()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not read the uploaded file.",
+		})
+		return nil, nil, false
+	}
+
+	defer file.Close()
+
+	var columns []string
+	var rows []map[string]string
+
+	switch format {
+	case "csv":
+		columns, rows, err = parseCSVRows(file)
+	case "json":
+		columns, rows, err = parseJSONRows(file)
+	default:
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "The file has to be csv or json, send format when the file name doesn't tell.",
+		})
+		return nil, nil, false
+	}
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("Could not parse the %s file: %v", format, err),
+		})
+		return nil, nil, false
+	}
+
+	if len(rows) == 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "The file has no rows.",
+		})
+		return nil, nil, false
+	}
+
+	if len(rows) > maxImportRows {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": fmt.Sprintf("At most %d rows can be imported at once.", maxImportRows),
+		})
+		return nil, nil, false
+	}
+
+	return columns, rows, true
+}
+
+func parseCSVRows(reader io.Reader) ([]string, []map[string]string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, errors.New("the header row is missing")
+	}
+
+	columns := records[0]
+
+	// spreadsheets like to start the file with a byte order mark
+	columns[0] = strings.TrimPrefix(columns[0], "\ufeff")
+
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	records = records[1:]
+
+	// sheets often end with lines of empty cells
+	for len(records) > 0 && !slices.ContainsFunc(records[len(records)-1], func(cell string) bool { return strings.TrimSpace(cell) != "" }) {
+		records = records[:len(records)-1]
+	}
+
+	var rows []map[string]string
+
+	for _, record := range records {
+		row := make(map[string]string)
+
+		for i, column := range columns {
+			if i < len(record) {
+				row[column] = unquoteCSVFormula(strings.TrimSpace(record[i]))
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return columns, rows, nil
+}
+
+func parseJSONRows(reader io.Reader) ([]string, []map[string]string, error) {
+	var objects []map[string]any
+
+	err := json.NewDecoder(reader).Decode(&objects)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var columns []string
+	var rows []map[string]string
+
+	for _, object := range objects {
+		row := make(map[string]string)
+
+		for column, value := range object {
+			row[column] = strings.TrimSpace(importCellText(value))
+
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	slices.Sort(columns)
+
+	return columns, rows, nil
+}
+
+// importCellText gives a json value the text it would have in a spreadsheet cell
+func importCellText(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		items := make([]string, len(value))
+
+		for i, item := range value {
+			items[i] = importCellText(item)
+		}
+
+		return strings.Join(items, ",")
+	default:
+		data, _ := json.Marshal(value)
+
+		return string(data)
+	}
+}
+
+// suggestImportMapping maps every field to the column named like it, ignoring the case
+func suggestImportMapping(columns []string, customFields []models.CustomField) map[string]string {
+	fields := slices.Clone(importFields)
+
+	for _, field := range customFields {
+		fields = append(fields, "custom_fields."+field.Key)
+	}
+
+	mapping := make(map[string]string)
+
+	for _, field := range fields {
+		for _, column := range columns {
+			name := strings.ToLower(strings.ReplaceAll(column, " ", "_"))
+
+			if name == field || "custom_fields."+name == field {
+				mapping[field] = column
+				break
+			}
+		}
+	}
+
+	return mapping
+}
+
+func parseImportMapping(context *gin.Context, columns []string, customFields []models.CustomField) (map[string]string, bool) {
+	rawMapping := context.PostForm("mapping")
+
+	if rawMapping == "" {
+		return suggestImportMapping(columns, customFields), true
+	}
+
+	var mapping map[string]string
+
+	err := json.Unmarshal([]byte(rawMapping), &mapping)
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "mapping has to be a json object from task field to column.",
+		})
+		return nil, false
+	}
+
+	errorsOutput := make(map[string]string)
+
+	for field, column := range mapping {
+		key, isCustomField := strings.CutPrefix(field, "custom_fields.")
+
+		if !slices.Contains(importFields, field) && !(isCustomField && slices.ContainsFunc(customFields, func(customField models.CustomField) bool { return customField.Key == key })) {
+			errorsOutput["mapping."+field] = fmt.Sprintf("%s is not a task field", field)
+			continue
+		}
+
+		if !slices.Contains(columns, column) {
+			errorsOutput["mapping."+field] = fmt.Sprintf("%s is not a column of the file", column)
+		}
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return nil, false
+	}
+
+	return mapping, true
+}
+
+// importResolver remembers the categories and the users it already looked up,
+// the same few come back on most rows
+type importResolver struct {
+	categories map[string]int64
+	users      map[string]int64
+}
+
+func newImportResolver() *importResolver {
+	return &importResolver{categories: make(map[string]int64), users: make(map[string]int64)}
+}
+
+// buildTask turns the row into a task and checks it like createTask does, the errors
+// are keyed by task field
+func (resolver *importResolver) buildTask(row map[string]string, mapping map[string]string, customFields []models.CustomField) (*models.Task, map[string]string, error) {
+	errorsOutput := make(map[string]string)
+
+	cell := func(field string) string {
+		column, ok := mapping[field]
+
+		if !ok {
+			return ""
+		}
+
+		return row[column]
+	}
+
+	now := time.Now()
+
+	task := models.Task{
+		Title:        cell("title"),
+		Description:  cell("description"),
+		Priority:     models.Priority(cell("priority")),
+		Status:       models.Status(cell("status")),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		AssigneesIDs: []int64{},
+	}
+
+	if dueDate := cell("due_date"); dueDate != "" {
+		var err error
+
+		task.DueDate, err = time.Parse(time.RFC3339, dueDate)
+
+		if err != nil {
+			task.DueDate, err = time.Parse("2006-01-02", dueDate)
+		}
+
+		if err != nil {
+			errorsOutput["due_date"] = "due_date must be a date like 2024-01-31 or 2024-01-31T09:00:00Z"
+		}
+	}
+
+	if estimate := cell("estimate_minutes"); estimate != "" {
+		var err error
+
+		task.EstimateMinutes, err = strconv.ParseInt(estimate, 10, 64)
+
+		if err != nil {
+			errorsOutput["estimate_minutes"] = "estimate_minutes must be a whole number"
+		}
+	}
+
+	if title := cell("category"); title != "" {
+		categoryId, err := resolver.category(title)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			errorsOutput["category"] = fmt.Sprintf("%s is not a category", title)
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		task.CategoryID = categoryId
+	}
+
+	for _, login := range strings.FieldsFunc(cell("assignees"), func(r rune) bool { return r == ',' || r == ';' }) {
+		login = strings.TrimSpace(login)
+
+		if login == "" {
+			continue
+		}
+
+		userId, err := resolver.user(login)
+
+		if problem := userProblem(login, err); problem != "" {
+			errorsOutput["assignees"] = problem
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !slices.Contains(task.AssigneesIDs, userId) {
+			task.AssigneesIDs = append(task.AssigneesIDs, userId)
+		}
+	}
+
+	customValues := make(map[string]any)
+
+	for _, field := range customFields {
+		text := cell("custom_fields." + field.Key)
+
+		if text == "" {
+			continue
+		}
+
+		value, err := resolver.customValue(field, text)
+
+		if problem := userProblem(text, err); problem != "" {
+			errorsOutput["custom_fields."+field.Key] = problem
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		customValues[field.Key] = value
+	}
+
+	bindingErrors, _ := utils.ValidationErrorMessages(binding.Validator.ValidateStruct(task), task)
+
+	for field, message := range bindingErrors {
+		if _, ok := errorsOutput[field]; !ok {
+			errorsOutput[field] = message
+		}
+	}
+
+	if task.Status != "" {
+		problem, err := taskStatusProblem(task.Status, "")
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if problem != "" {
+			errorsOutput["status"] = problem
+		}
+	}
+
+	if task.Priority != "" {
+		_, err := models.GetPriorityByName(task.Priority)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			errorsOutput["priority"] = fmt.Sprintf("%s is not a priority", task.Priority)
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	values, customErrors, err := models.ValidateCustomValues(task.CategoryID, customValues)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for field, message := range customErrors {
+		if _, ok := errorsOutput[field]; !ok {
+			errorsOutput[field] = message
+		}
+	}
+
+	task.CustomFields = values
+
+	if len(errorsOutput) > 0 {
+		return nil, errorsOutput, nil
+	}
+
+	return &task, nil, nil
+}
+
+func (resolver *importResolver) category(title string) (int64, error) {
+	key := strings.ToLower(title)
+
+	if categoryId, ok := resolver.categories[key]; ok {
+		return categoryId, nil
+	}
+
+	category, err := models.GetCategoryByTitle(title)
+
+	if err != nil {
+		return 0, err
+	}
+
+	resolver.categories[key] = category.ID
+
+	return category.ID, nil
+}
+
+// user is cached by the login as it was written, the usernames are case sensitive
+// so "bob" and "Bob" can be two users
+func (resolver *importResolver) user(login string) (int64, error) {
+	if userId, ok := resolver.users[login]; ok {
+		return userId, nil
+	}
+
+	userId, err := models.GetUserIDByLogin(login)
+
+	if err != nil {
+		return 0, err
+	}
+
+	resolver.users[login] = userId
+
+	return userId, nil
+}
+
+// userProblem is the error message of a login that does not give one user, "" when the error is not about the login
+func userProblem(login string, err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Sprintf("%s is not a user", login)
+	}
+
+	if errors.Is(err, models.ErrAmbiguousLogin) {
+		return fmt.Sprintf("more than one user is called %s, use the email", login)
+	}
+
+	return ""
+}
+
+// customValue turns the cell text into the value the json api would get for the field,
+// user fields take an email or a username like the assignees do, or the user id.
+// Text that is not a number stays text, ValidateCustomValues tells it is not valid
+func (resolver *importResolver) customValue(field models.CustomField, text string) (any, error) {
+	switch field.Type {
+	case models.CustomFieldNumber:
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number, nil
+		}
+
+		return text, nil
+	case models.CustomFieldUser:
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number, nil
+		}
+
+		userId, err := resolver.user(text)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return float64(userId), nil
+	case models.CustomFieldMultiSelect:
+		var options []any
+
+		for _, option := range strings.Split(text, ",") {
+			options = append(options, strings.TrimSpace(option))
+		}
+
+		return options, nil
+	default:
+		return text, nil
+	}
+}
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		columns []string
+		rows    []map[string]string
+	}{
+		{
+			name:    "plain",
+			file:    "title,status\nFirst,todo\nSecond,done\n",
+			columns: []string{"title", "status"},
+			rows:    []map[string]string{{"title": "First", "status": "todo"}, {"title": "Second", "status": "done"}},
+		},
+		{
+			name:    "byte order mark and spaces",
+			file:    "\ufeff title , status\n  First ,  todo  \n",
+			columns: []string{"title", "status"},
+			rows:    []map[string]string{{"title": "First", "status": "todo"}},
+		},
+		{
+			name:    "short and long rows",
+			file:    "title,status\nOnly title\nFirst,todo,extra\n",
+			columns: []string{"title", "status"},
+			rows:    []map[string]string{{"title": "Only title"}, {"title": "First", "status": "todo"}},
+		},
+		{
+			name:    "empty lines at the end",
+			file:    "title,status\nFirst,todo\n,\n , \n",
+			columns: []string{"title", "status"},
+			rows:    []map[string]string{{"title": "First", "status": "todo"}},
+		},
+		{
+			name:    "quoted cells",
+			file:    "title,assignees\n\"Fix, then ship\",\"a@b.com;c@d.com\"\n",
+			columns: []string{"title", "assignees"},
+			rows:    []map[string]string{{"title": "Fix, then ship", "assignees": "a@b.com;c@d.com"}},
+		},
+		{
+			name:    "quoted formulas of an export",
+			file:    "title,description\n'=SUM(A1),'-2 days\n'plain,it's fine\n",
+			columns: []string{"title", "description"},
+			rows:    []map[string]string{{"title": "=SUM(A1)", "description": "-2 days"}, {"title": "'plain", "description": "it's fine"}},
+		},
+		{
+			name:    "header only",
+			file:    "title,status\n",
+			columns: []string{"title", "status"},
+			rows:    nil,
+		},
+	}
+
+	for _, test := range tests {
+		columns, rows, err := parseCSVRows(strings.NewReader(test.file))
+
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !slices.Equal(columns, test.columns) {
+			t.Errorf("%s: columns %q, want %q", test.name, columns, test.columns)
+		}
+
+		if !slices.EqualFunc(rows, test.rows, maps.Equal) {
+			t.Errorf("%s: rows %v, want %v", test.name, rows, test.rows)
+		}
+	}
+}
+
+func TestParseCSVRowsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"empty file", ""},
+		{"unclosed quote", "title\n\"never closed\n"},
+	}
+
+	for _, test := range tests {
+		_, _, err := parseCSVRows(strings.NewReader(test.file))
+
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestImportCellText(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{float64(3), "3"},
+		{2.5, "2.5"},
+		{float64(1e21), "1000000000000000000000"},
+		{true, "true"},
+		{[]any{"a@b.com", "c@d.com"}, "a@b.com,c@d.com"},
+		{[]any{float64(1), nil, "x"}, "1,,x"},
+		{map[string]any{"a": float64(1)}, `{"a":1}`},
+	}
+
+	for _, test := range tests {
+		if got := importCellText(test.value); got != test.want {
+			t.Errorf("importCellText(%#v) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func createLabel(context *gin.Context) {
+	var label models.Label
+
+	err := context.ShouldBindJSON(&label)
+
+	if utils.CheckValidationErrors(context, err, label) {
+		return
+	}
+
+	err = label.Save()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not create the label",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Label was created successfully!",
+		"data":    label,
+	})
+}
+
+func updateLabel(context *gin.Context) {
+	labelId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Label id could not be parsed.",
+		})
+		return
+	}
+
+	_, err = models.GetLabel(*labelId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No label was found!",
+		})
+		return
+	}
+
+	var updatedLabel models.Label
+
+	err = context.ShouldBindJSON(&updatedLabel)
+
+	if utils.CheckValidationErrors(context, err, updatedLabel) {
+		return
+	}
+
+	updatedLabel.ID = *labelId
+
+	err = updatedLabel.Update()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the label",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Label was updated successfully!",
+		"data":    updatedLabel,
+	})
+}
+
+func deleteLabel(context *gin.Context) {
+	labelId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Label id could not be parsed.",
+		})
+		return
+	}
+
+	label, err := models.GetLabel(*labelId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No label was found!",
+		})
+		return
+	}
+
+	err = label.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the label",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Label was deleted successfully!",
+	})
+}
+
+func getLabels(context *gin.Context) {
+	labels, err := models.GetAllLabels()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get all labels",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Fetching all labels successfully",
+		"data":    labels,
+	})
+}
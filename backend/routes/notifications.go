@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getNotifications(context *gin.Context) {
+	userId := context.GetInt64("userId")
+	limit, offset := parsePagination(context)
+
+	notifications, err := models.GetNotifications(userId, context.Query("unread") == "true", limit, offset)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the notifications.",
+		})
+		return
+	}
+
+	unreadCount, err := models.CountUnreadNotifications(userId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the notifications.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message":      "successful",
+		"data":         notifications,
+		"unread_count": unreadCount,
+	})
+}
+
+func markNotificationRead(context *gin.Context) {
+	notificationId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Notification id could not be parsed.",
+		})
+		return
+	}
+
+	err = models.MarkNotificationRead(context.GetInt64("userId"), *notificationId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No notification was found!",
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the notification.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Notification was marked as read.",
+	})
+}
+
+func markAllNotificationsRead(context *gin.Context) {
+	err := models.MarkAllNotificationsRead(context.GetInt64("userId"))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the notifications.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "All notifications were marked as read.",
+	})
+}
+
+func getNotificationPreferences(context *gin.Context) {
+	preferences, err := models.GetNotificationPreferences(context.GetInt64("userId"))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the notification preferences.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    preferences,
+	})
+}
+
+// updateNotificationPreferences takes a {"type": enabled} object, types not sent stay as they are
+func updateNotificationPreferences(context *gin.Context) {
+	var preferences map[models.NotificationType]bool
+
+	err := context.ShouldBindJSON(&preferences)
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body.",
+		})
+		return
+	}
+
+	errorsOutput := make(map[string]string)
+
+	for notificationType := range preferences {
+		if !slices.Contains(models.NotificationTypes, notificationType) {
+			errorsOutput[string(notificationType)] = fmt.Sprintf("%s is not a notification type", notificationType)
+		}
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return
+	}
+
+	userId := context.GetInt64("userId")
+
+	err = models.SaveNotificationPreferences(userId, preferences)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the notification preferences.",
+		})
+		return
+	}
+
+	getNotificationPreferences(context)
+}
@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getPriorityOptions(context *gin.Context) {
+	priorities, err := models.GetPriorities()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the priorities.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    priorities,
+	})
+}
+
+func createPriority(context *gin.Context) {
+	var priority models.PriorityLevel
+
+	err := context.ShouldBindJSON(&priority)
+
+	if utils.CheckValidationErrors(context, err, priority) {
+		return
+	}
+
+	err = priority.Save()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not create the priority, the name may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Priority was created successfully!",
+		"data":    priority,
+	})
+}
+
+// updatePriority renames the priority on its tasks as well
+func updatePriority(context *gin.Context) {
+	priorityId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Priority id could not be parsed.",
+		})
+		return
+	}
+
+	priority, err := models.GetPriority(*priorityId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No priority was found!",
+		})
+		return
+	}
+
+	err = context.ShouldBindJSON(priority)
+
+	if utils.CheckValidationErrors(context, err, *priority) {
+		return
+	}
+
+	priority.ID = *priorityId
+
+	err = priority.Update()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not update the priority, the name may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Priority was updated successfully!",
+		"data":    priority,
+	})
+}
+
+func deletePriority(context *gin.Context) {
+	priorityId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Priority id could not be parsed.",
+		})
+		return
+	}
+
+	priority, err := models.GetPriority(*priorityId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No priority was found!",
+		})
+		return
+	}
+
+	err = priority.Delete()
+
+	if errors.Is(err, models.ErrPriorityInUse) {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the priority.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Priority was deleted successfully!",
+	})
+}
+
+func checkTaskPriority(context *gin.Context, priority models.Priority) bool {
+	_, err := models.GetPriorityByName(priority)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors": gin.H{
+				"priority": fmt.Sprintf("%s is not a priority", priority),
+			},
+		})
+		return false
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the priority.",
+		})
+		return false
+	}
+
+	return true
+}
@@ -6,10 +6,16 @@ import (
 )
 
 func RegisterRoutes(server *gin.Engine) {
+	server.Use(middlewares.RequestID)
+
 	// user and auth routes
-	server.POST("/sign-up", signUpUser)
+	server.POST("/sign-up", middlewares.Idempotent, signUpUser)
 	server.POST("/login", login)
 
+	// the event stream and the websocket authenticate on their own as browsers can't send headers there
+	server.GET("/events", middlewares.AuthenticateStream, streamEvents)
+	server.GET("/ws", middlewares.AuthenticateStream, openSocket)
+
 	authenticatedRoutes := server.Group("/")
 	authenticatedRoutes.Use(middlewares.Authenticate)
 	authenticatedRoutes.DELETE("/user/:id", deleteUserAccount)
@@ -19,7 +25,8 @@ func RegisterRoutes(server *gin.Engine) {
 
 	// categories routes - it needs token
 	server.GET("/category", getCategories)
-	authenticatedRoutes.POST("/category", createCategory)
+	server.GET("/category/:id", getCategory)
+	authenticatedRoutes.POST("/category", middlewares.Idempotent, createCategory)
 	authenticatedRoutes.PUT("/category/:id", updateCategory)
 	authenticatedRoutes.DELETE("/category/:id", deleteCategory)
 
@@ -27,6 +34,106 @@ func RegisterRoutes(server *gin.Engine) {
 	authenticatedRoutes.GET("/status-options", getStatusOptions)
 	authenticatedRoutes.GET("/priority-options", getPriorityOptions)
 
+	// workflow status routes - admins manage them
+	authenticatedRoutes.POST("/statuses", middlewares.RequireAdmin, createStatus)
+	authenticatedRoutes.PUT("/statuses/:id", middlewares.RequireAdmin, updateStatus)
+	authenticatedRoutes.DELETE("/statuses/:id", middlewares.RequireAdmin, deleteStatus)
+
+	// priority routes - admins manage them and their sla targets
+	authenticatedRoutes.POST("/priorities", middlewares.RequireAdmin, createPriority)
+	authenticatedRoutes.PUT("/priorities/:id", middlewares.RequireAdmin, updatePriority)
+	authenticatedRoutes.DELETE("/priorities/:id", middlewares.RequireAdmin, deletePriority)
+
+	// custom field routes - admins define the fields, the values are sent with the task
+	authenticatedRoutes.GET("/custom-fields", getCustomFields)
+	authenticatedRoutes.POST("/custom-fields", middlewares.RequireAdmin, createCustomField)
+	authenticatedRoutes.PUT("/custom-fields/:id", middlewares.RequireAdmin, updateCustomField)
+	authenticatedRoutes.DELETE("/custom-fields/:id", middlewares.RequireAdmin, deleteCustomField)
+
+	// task template routes
+	authenticatedRoutes.GET("/templates", getTaskTemplates)
+	authenticatedRoutes.GET("/templates/:id", getTaskTemplate)
+	authenticatedRoutes.POST("/templates", createTaskTemplate)
+	authenticatedRoutes.PUT("/templates/:id", updateTaskTemplate)
+	authenticatedRoutes.DELETE("/templates/:id", deleteTaskTemplate)
+	authenticatedRoutes.POST("/templates/:id/instantiate", instantiateTaskTemplate)
+
 	// task routes
-	authenticatedRoutes.POST("task", createTask)
+	authenticatedRoutes.POST("task", middlewares.Idempotent, createTask)
+	authenticatedRoutes.GET("/tasks", getTasks)
+	authenticatedRoutes.POST("/tasks/bulk", bulkTasks)
+	authenticatedRoutes.POST("/import/tasks", importTasks)
+	authenticatedRoutes.POST("/import/tasks/preview", previewTaskImport)
+	authenticatedRoutes.GET("/export/tasks", exportTasks)
+	authenticatedRoutes.GET("/task/:id", getTask)
+	authenticatedRoutes.PUT("/task/:id", updateTask)
+	authenticatedRoutes.DELETE("/task/:id", deleteTask)
+	authenticatedRoutes.POST("/task/:id/archive", archiveTask)
+	authenticatedRoutes.POST("/task/:id/unarchive", unarchiveTask)
+
+	// trash routes - deleted tasks, categories and users stay here until they are purged
+	authenticatedRoutes.GET("/trash", getTrash)
+	authenticatedRoutes.POST("/trash/tasks/:id/restore", restoreTask)
+	authenticatedRoutes.POST("/trash/categories/:id/restore", restoreCategory)
+	authenticatedRoutes.POST("/trash/users/:id/restore", middlewares.RequireAdmin, restoreUser)
+
+	// board routes
+	authenticatedRoutes.GET("/board", getBoard)
+	authenticatedRoutes.POST("/board/move", moveTask)
+
+	// checklist routes
+	authenticatedRoutes.GET("/task/:id/checklist", getTaskChecklist)
+	authenticatedRoutes.POST("/task/:id/checklist", addChecklistItem)
+	authenticatedRoutes.PUT("/task/:id/checklist/order", reorderChecklist)
+	authenticatedRoutes.POST("/checklist/:id/toggle", toggleChecklistItem)
+	authenticatedRoutes.DELETE("/checklist/:id", deleteChecklistItem)
+
+	// time tracking routes
+	authenticatedRoutes.POST("/task/:id/timer/start", startTimer)
+	authenticatedRoutes.POST("/timer/stop", stopTimer)
+	authenticatedRoutes.GET("/timer", getRunningTimer)
+	authenticatedRoutes.GET("/task/:id/time-entries", getTaskTimeEntries)
+	authenticatedRoutes.POST("/task/:id/time-entries", createTimeEntry)
+	authenticatedRoutes.DELETE("/time-entries/:id", deleteTimeEntry)
+	authenticatedRoutes.GET("/reports/time", getTimeReport)
+
+	// activity and audit routes
+	authenticatedRoutes.GET("/task/:id/activity", getTaskActivity)
+	authenticatedRoutes.GET("/audit", middlewares.RequireAdmin, getAuditEvents)
+
+	// comment routes
+	authenticatedRoutes.GET("/task/:id/comments", getTaskComments)
+	authenticatedRoutes.POST("/task/:id/comments", createComment)
+
+	// notification routes
+	authenticatedRoutes.GET("/notifications", getNotifications)
+	authenticatedRoutes.POST("/notifications/:id/read", markNotificationRead)
+	authenticatedRoutes.POST("/notifications/read-all", markAllNotificationsRead)
+	authenticatedRoutes.GET("/notifications/preferences", getNotificationPreferences)
+	authenticatedRoutes.PUT("/notifications/preferences", updateNotificationPreferences)
+
+	// email digest routes
+	authenticatedRoutes.GET("/digest/settings", getDigestSettings)
+	authenticatedRoutes.PUT("/digest/settings", updateDigestSettings)
+
+	// webhook routes - admins only
+	authenticatedRoutes.GET("/webhooks", middlewares.RequireAdmin, getWebhooks)
+	authenticatedRoutes.POST("/webhooks", middlewares.RequireAdmin, createWebhook)
+	authenticatedRoutes.PUT("/webhooks/:id", middlewares.RequireAdmin, updateWebhook)
+	authenticatedRoutes.DELETE("/webhooks/:id", middlewares.RequireAdmin, deleteWebhook)
+	authenticatedRoutes.GET("/webhooks/:id/deliveries", middlewares.RequireAdmin, getWebhookDeliveries)
+	authenticatedRoutes.POST("/webhook-deliveries/:id/redeliver", middlewares.RequireAdmin, redeliverWebhook)
+
+	// label routes
+	authenticatedRoutes.GET("/label", getLabels)
+	authenticatedRoutes.POST("/label", createLabel)
+	authenticatedRoutes.PUT("/label/:id", updateLabel)
+	authenticatedRoutes.DELETE("/label/:id", deleteLabel)
+
+	// attachment routes
+	authenticatedRoutes.POST("/task/:id/attachments", uploadAttachment)
+	authenticatedRoutes.GET("/task/:id/attachments", getTaskAttachments)
+	authenticatedRoutes.GET("/attachments/:id", downloadAttachment)
+	authenticatedRoutes.GET("/attachments/:id/thumb", getAttachmentThumbnail)
+	authenticatedRoutes.DELETE("/attachments/:id", deleteAttachment)
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/abolfazlcodes/task-dashboard/backend/realtime"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// openSocket upgrades to the presence websocket, the origin is not checked as
+// the connection is authenticated with the token and not with cookies
+func openSocket(context *gin.Context) {
+	userId := context.GetInt64("userId")
+
+	server := websocket.Server{
+		Handler: func(conn *websocket.Conn) {
+			realtime.ServeSocket(conn, userId)
+		},
+	}
+
+	server.ServeHTTP(context.Writer, context.Request)
+}
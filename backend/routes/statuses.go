@@ -0,0 +1,221 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getStatusOptions(context *gin.Context) {
+	statuses, err := models.GetStatuses()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the statuses.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    statuses,
+	})
+}
+
+func createStatus(context *gin.Context) {
+	var status models.WorkflowStatus
+
+	err := context.ShouldBindJSON(&status)
+
+	if utils.CheckValidationErrors(context, err, status) || !checkStatusTransitions(context, status) {
+		return
+	}
+
+	err = status.Save()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not create the status, the name may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Status was created successfully!",
+		"data":    status,
+	})
+}
+
+// updateStatus renames the status on its tasks as well
+func updateStatus(context *gin.Context) {
+	statusId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Status id could not be parsed.",
+		})
+		return
+	}
+
+	status, err := models.GetStatus(*statusId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No status was found!",
+		})
+		return
+	}
+
+	err = context.ShouldBindJSON(status)
+
+	if utils.CheckValidationErrors(context, err, *status) || !checkStatusTransitions(context, *status) {
+		return
+	}
+
+	status.ID = *statusId
+
+	err = status.Update()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not update the status, the name may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Status was updated successfully!",
+		"data":    status,
+	})
+}
+
+func deleteStatus(context *gin.Context) {
+	statusId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Status id could not be parsed.",
+		})
+		return
+	}
+
+	status, err := models.GetStatus(*statusId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No status was found!",
+		})
+		return
+	}
+
+	err = status.Delete()
+
+	if errors.Is(err, models.ErrStatusInUse) {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the status.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Status was deleted successfully!",
+	})
+}
+
+func checkStatusTransitions(context *gin.Context, status models.WorkflowStatus) bool {
+	for _, name := range status.Transitions {
+		if name == status.Name {
+			continue
+		}
+
+		_, err := models.GetStatusByName(name)
+
+		if err == nil {
+			continue
+		}
+
+		if errors.Is(err, sql.ErrNoRows) {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "Request validation errors.",
+				"errors": gin.H{
+					"transitions": fmt.Sprintf("%s is not a status", name),
+				},
+			})
+		} else {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not check the statuses.",
+			})
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// checkTaskStatus makes sure the status exists and, when the task had another status
+// before, that the workflow allows the move
+func checkTaskStatus(context *gin.Context, status, previous models.Status) bool {
+	problem, err := taskStatusProblem(status, previous)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the status.",
+		})
+		return false
+	}
+
+	if problem != "" {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors": gin.H{
+				"status": problem,
+			},
+		})
+		return false
+	}
+
+	return true
+}
+
+// taskStatusProblem tells why a task can't get the status, it is empty when it can
+func taskStatusProblem(status, previous models.Status) (string, error) {
+	_, err := models.GetStatusByName(status)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Sprintf("%s is not a status", status), nil
+	}
+
+	if err != nil || previous == "" {
+		return "", err
+	}
+
+	previousStatus, err := models.GetStatusByName(previous)
+
+	// the previous status may have been deleted since, nothing to check then
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if !previousStatus.CanMoveTo(status) {
+		return fmt.Sprintf("a task can't move from %s to %s", previous, status), nil
+	}
+
+	return "", nil
+}
@@ -1,7 +1,12 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/abolfazlcodes/task-dashboard/backend/models"
@@ -19,10 +24,14 @@ func createTask(context *gin.Context) {
 		return
 	}
 
+	if !checkTaskStatus(context, task.Status, "") || !checkTaskPriority(context, task.Priority) || !checkTaskCategory(context, task.CategoryID, 0) || !checkTaskAssignees(context, task.AssigneesIDs, nil) || !checkTaskLabels(context, task.LabelIDs) || !checkTaskCustomFields(context, &task) {
+		return
+	}
+
 	task.CreatedAt = time.Now()
 	task.UpdatedAt = time.Now()
 
-	err = task.Save()
+	err = task.Save(requestActor(context))
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
@@ -37,46 +46,432 @@ func createTask(context *gin.Context) {
 	})
 }
 
-func getStatusOptions(context *gin.Context) {
-	statusOptions := []utils.Option{
-		{
-			Value: 1,
-			Label: "done",
-		},
-		{
-			Value: 2,
-			Label: "in-progress",
-		},
-		{
-			Value: 3,
-			Label: "todo",
-		},
+func updateTask(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
 	}
 
+	existingTask, err := models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	if !checkIfMatch(context, existingTask.Version, existingTask) {
+		return
+	}
+
+	var updatedTask models.Task
+
+	err = context.ShouldBindJSON(&updatedTask)
+
+	if utils.CheckValidationErrors(context, err, updatedTask) {
+		return
+	}
+
+	// custom fields that are not sent keep their values, unless the new category does not have them
+	if updatedTask.CustomFields == nil {
+		updatedTask.CustomFields, err = models.ApplicableCustomValues(updatedTask.CategoryID, existingTask.CustomFields)
+
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not check the custom fields.",
+			})
+			return
+		}
+	}
+
+	if !checkTaskStatus(context, updatedTask.Status, existingTask.Status) || !checkTaskPriority(context, updatedTask.Priority) || !checkTaskCategory(context, updatedTask.CategoryID, existingTask.CategoryID) || !checkTaskAssignees(context, updatedTask.AssigneesIDs, existingTask.AssigneesIDs) || !checkTaskLabels(context, updatedTask.LabelIDs) || !checkTaskCustomFields(context, &updatedTask) {
+		return
+	}
+
+	updatedTask.ID = existingTask.ID
+	updatedTask.CreatedAt = existingTask.CreatedAt
+	updatedTask.UpdatedAt = time.Now()
+	updatedTask.Version = existingTask.Version
+
+	err = updatedTask.Update(requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondTaskConflict(context, existingTask.ID)
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the task.",
+		})
+		return
+	}
+
+	setETag(context, existingTask.Version+1)
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Task updated successfully",
+	})
+}
+
+func getTask(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	task, err := models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	setETag(context, task.Version)
+
 	context.JSON(http.StatusOK, gin.H{
 		"message": "successful",
-		"data":    statusOptions,
+		"data":    task,
+	})
+}
+
+// deleteTask moves the task to the trash, it can be restored until it is purged
+func deleteTask(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	task, err := models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	if !checkIfMatch(context, task.Version, task) {
+		return
+	}
+
+	err = task.Delete(requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondTaskConflict(context, task.ID)
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the task.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Task was deleted successfully!",
+	})
+}
+
+func archiveTask(context *gin.Context) {
+	task, ok := loadTaskForArchive(context)
+
+	if !ok {
+		return
+	}
+
+	if task.ArchivedAt != nil {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": "The task is archived already.",
+		})
+		return
+	}
+
+	err := task.Archive(requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondTaskConflict(context, task.ID)
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not archive the task.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Task was archived successfully!",
+	})
+}
+
+func unarchiveTask(context *gin.Context) {
+	task, ok := loadTaskForArchive(context)
+
+	if !ok {
+		return
+	}
+
+	if task.ArchivedAt == nil {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": "The task is not archived.",
+		})
+		return
+	}
+
+	err := task.Unarchive(requestActor(context))
+
+	if errors.Is(err, models.ErrVersionConflict) {
+		respondTaskConflict(context, task.ID)
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not unarchive the task.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Task was unarchived successfully!",
 	})
 }
 
-func getPriorityOptions(context *gin.Context) {
-	priorityOptions := []utils.Option{
-		{
-			Value: 1,
-			Label: "low",
-		},
-		{
-			Value: 2,
-			Label: "medium",
-		},
-		{
-			Value: 3,
-			Label: "high",
-		},
+func loadTaskForArchive(context *gin.Context) (*models.Task, bool) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return nil, false
+	}
+
+	task, err := models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return nil, false
+	}
+
+	return task, true
+}
+
+func getTasks(context *gin.Context) {
+	filter, err := parseTaskFilter(context.Request.URL.Query())
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	tasks, err := models.GetTasks(*filter)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the tasks.",
+		})
+		return
 	}
 
 	context.JSON(http.StatusOK, gin.H{
 		"message": "successful",
-		"data":    priorityOptions,
+		"data":    tasks,
 	})
 }
+
+// parseTaskFilter reads the task list filters from the query string,
+// labels are given as comma separated ids e.g. ?labels_any=1,2&labels_none=3.
+// Custom fields are filtered like ?cf[environment]=prod and sorted like ?sort=-cf.story_points
+func parseTaskFilter(query url.Values) (*models.TaskFilter, error) {
+	filter := models.TaskFilter{
+		Status:   models.Status(query.Get("status")),
+		Priority: models.Priority(query.Get("priority")),
+	}
+
+	switch query.Get("sla") {
+	case "":
+	case string(models.SLABreached):
+		filter.SLABreached = true
+	default:
+		return nil, errors.New("sla can only be breached.")
+	}
+
+	switch query.Get("include_archived") {
+	case "", "false":
+	case "true":
+		filter.IncludeArchived = true
+	default:
+		return nil, errors.New("include_archived can only be true or false.")
+	}
+
+	var err error
+
+	if categoryId := query.Get("category_id"); categoryId != "" {
+		id, err := utils.ConvertStringToInt(categoryId)
+
+		if err != nil {
+			return nil, errors.New("category_id could not be parsed.")
+		}
+
+		filter.CategoryID = *id
+	}
+
+	if parentId := query.Get("parent_id"); parentId != "" {
+		id, err := utils.ConvertStringToInt(parentId)
+
+		if err != nil {
+			return nil, errors.New("parent_id could not be parsed.")
+		}
+
+		filter.ParentID = *id
+	}
+
+	filter.LabelsAny, err = utils.ParseIDList(query.Get("labels_any"))
+
+	if err != nil {
+		return nil, errors.New("labels_any could not be parsed.")
+	}
+
+	filter.LabelsAll, err = utils.ParseIDList(query.Get("labels_all"))
+
+	if err != nil {
+		return nil, errors.New("labels_all could not be parsed.")
+	}
+
+	filter.LabelsNone, err = utils.ParseIDList(query.Get("labels_none"))
+
+	if err != nil {
+		return nil, errors.New("labels_none could not be parsed.")
+	}
+
+	for name := range query {
+		key, ok := strings.CutPrefix(name, "cf[")
+
+		if !ok || !strings.HasSuffix(key, "]") {
+			continue
+		}
+
+		customFilter, err := models.ParseCustomFieldFilter(strings.TrimSuffix(key, "]"), query.Get(name))
+
+		if err != nil {
+			return nil, err
+		}
+
+		filter.CustomFields = append(filter.CustomFields, *customFilter)
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		filter.SortDesc = strings.HasPrefix(sort, "-")
+
+		key, ok := strings.CutPrefix(strings.TrimPrefix(sort, "-"), "cf.")
+
+		if !ok {
+			return nil, errors.New("sort can only be a custom field like cf.story_points.")
+		}
+
+		filter.SortField, err = models.GetCustomFieldByKey(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a custom field.", key)
+		}
+	}
+
+	return &filter, nil
+}
+
+// checkTaskCategory accepts no category and the category the task already has, even when
+// that one is in the trash meanwhile
+func checkTaskCategory(context *gin.Context, categoryId, currentCategoryId int64) bool {
+	if categoryId == 0 || categoryId == currentCategoryId {
+		return true
+	}
+
+	_, err := models.GetCategory(categoryId)
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors": gin.H{
+				"category_id": "category_id is not a category",
+			},
+		})
+		return false
+	}
+
+	return true
+}
+
+// checkTaskAssignees only checks the new assignees, the ones the task already has
+// stay even when they are in the trash
+func checkTaskAssignees(context *gin.Context, assigneesIDs, currentIDs []int64) bool {
+	added := slices.DeleteFunc(slices.Clone(assigneesIDs), func(userId int64) bool {
+		return slices.Contains(currentIDs, userId)
+	})
+
+	usersExist, err := models.UsersExist(added)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the assignees.",
+		})
+		return false
+	}
+
+	if !usersExist {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors": gin.H{
+				"assignees_ids": "assignees_ids contains unknown users",
+			},
+		})
+		return false
+	}
+
+	return true
+}
+
+func checkTaskLabels(context *gin.Context, labelIDs []int64) bool {
+	labelsExist, err := models.LabelsExist(labelIDs)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the labels.",
+		})
+		return false
+	}
+
+	if !labelsExist {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors": gin.H{
+				"label_ids": "label_ids contains unknown labels",
+			},
+		})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,284 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getTaskTemplates(context *gin.Context) {
+	templates, err := models.GetTaskTemplates()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the templates.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    templates,
+	})
+}
+
+func getTaskTemplate(context *gin.Context) {
+	templateId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Template id could not be parsed.",
+		})
+		return
+	}
+
+	template, err := models.GetTaskTemplate(*templateId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No template was found!",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    template,
+	})
+}
+
+func createTaskTemplate(context *gin.Context) {
+	var template models.TaskTemplate
+
+	err := context.ShouldBindJSON(&template)
+
+	if utils.CheckValidationErrors(context, err, template) || !checkTaskTemplate(context, &template) {
+		return
+	}
+
+	template.CreatedBy = context.GetInt64("userId")
+
+	err = template.Save()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not create the template, the name may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Template was created successfully!",
+		"data":    template,
+	})
+}
+
+func updateTaskTemplate(context *gin.Context) {
+	templateId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Template id could not be parsed.",
+		})
+		return
+	}
+
+	existingTemplate, err := models.GetTaskTemplate(*templateId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No template was found!",
+		})
+		return
+	}
+
+	var template models.TaskTemplate
+
+	err = context.ShouldBindJSON(&template)
+
+	if utils.CheckValidationErrors(context, err, template) || !checkTaskTemplate(context, &template) {
+		return
+	}
+
+	template.ID = existingTemplate.ID
+	template.CreatedBy = existingTemplate.CreatedBy
+	template.CreatedAt = existingTemplate.CreatedAt
+
+	err = template.Update()
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Could not update the template, the name may already be taken.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Template was updated successfully!",
+		"data":    template,
+	})
+}
+
+func deleteTaskTemplate(context *gin.Context) {
+	templateId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Template id could not be parsed.",
+		})
+		return
+	}
+
+	template, err := models.GetTaskTemplate(*templateId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No template was found!",
+		})
+		return
+	}
+
+	err = template.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the template.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Template was deleted successfully!",
+	})
+}
+
+// instantiateTaskTemplate creates the task of the template, the body is optional
+// e.g. {"variables": {"name": "Sara"}, "start_date": "2024-01-31"}
+func instantiateTaskTemplate(context *gin.Context) {
+	templateId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Template id could not be parsed.",
+		})
+		return
+	}
+
+	template, err := models.GetTaskTemplate(*templateId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No template was found!",
+		})
+		return
+	}
+
+	var instance models.TemplateInstance
+
+	err = context.ShouldBindJSON(&instance)
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request body.",
+		})
+		return
+	}
+
+	errorsOutput := make(map[string]string)
+
+	startDate := time.Now().UTC().Truncate(24 * time.Hour)
+
+	if instance.StartDate != "" {
+		startDate, err = time.Parse("2006-01-02", instance.StartDate)
+
+		if err != nil {
+			errorsOutput["start_date"] = "start_date must be a date like 2024-01-31"
+		}
+	}
+
+	var missingVariables []string
+
+	for _, name := range template.Variables() {
+		if _, ok := instance.Variables[name]; !ok {
+			missingVariables = append(missingVariables, name)
+		}
+	}
+
+	if len(missingVariables) > 0 {
+		errorsOutput["variables"] = "values are missing for " + strings.Join(missingVariables, ", ")
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return
+	}
+
+	// priorities and custom fields may have changed since the template was saved
+	if !checkTaskTemplate(context, template) {
+		return
+	}
+
+	task, err := template.Instantiate(instance, startDate, requestActor(context))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not create the task from the template.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Task created successfully",
+		"data":    task,
+	})
+}
+
+func checkTaskTemplate(context *gin.Context, template *models.TaskTemplate) bool {
+	if !checkTaskPriority(context, template.Priority) || !checkTaskLabels(context, template.LabelIDs) || !checkTaskAssignees(context, template.AssigneesIDs, nil) {
+		return false
+	}
+
+	for _, subtask := range template.Subtasks {
+		if subtask.Priority != "" && !checkTaskPriority(context, subtask.Priority) {
+			return false
+		}
+
+		if !checkTaskAssignees(context, subtask.AssigneesIDs, nil) {
+			return false
+		}
+	}
+
+	if template.CategoryID != 0 {
+		_, err := models.GetCategory(template.CategoryID)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "Request validation errors.",
+				"errors": gin.H{
+					"category_id": "category_id is not a category",
+				},
+			})
+			return false
+		}
+	}
+
+	// the custom fields are checked the way they will be on the created task
+	task := models.Task{CategoryID: template.CategoryID, CustomFields: template.CustomFields}
+
+	if !checkTaskCustomFields(context, &task) {
+		return false
+	}
+
+	template.CustomFields = task.CustomFields
+
+	return true
+}
@@ -0,0 +1,269 @@
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type stopTimerRequest struct {
+	Note string `json:"note" binding:"max=250"`
+}
+
+func startTimer(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	_, err = models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	entry, err := models.StartTimer(*taskId, context.GetInt64("userId"))
+
+	if errors.Is(err, models.ErrTimerAlreadyRunning) {
+		context.JSON(http.StatusConflict, gin.H{
+			"message": "You already have a running timer, stop it first.",
+			"data":    entry,
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not start the timer.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Timer was started.",
+		"data":    entry,
+	})
+}
+
+func stopTimer(context *gin.Context) {
+	var request stopTimerRequest
+
+	// the body is optional, it only carries a note
+	if context.Request.ContentLength != 0 {
+		err := context.ShouldBindJSON(&request)
+
+		if utils.CheckValidationErrors(context, err, request) {
+			return
+		}
+	}
+
+	entry, err := models.StopTimer(context.GetInt64("userId"), request.Note)
+
+	if errors.Is(err, models.ErrNoRunningTimer) {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "You have no running timer.",
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not stop the timer.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Timer was stopped.",
+		"data":    entry,
+	})
+}
+
+func getRunningTimer(context *gin.Context) {
+	entry, err := models.GetRunningTimer(context.GetInt64("userId"))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		context.JSON(http.StatusOK, gin.H{
+			"message": "You have no running timer.",
+			"data":    nil,
+		})
+		return
+	}
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the running timer.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    entry,
+	})
+}
+
+func createTimeEntry(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	_, err = models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No task was found!",
+		})
+		return
+	}
+
+	var manualEntry models.ManualTimeEntry
+
+	err = context.ShouldBindJSON(&manualEntry)
+
+	if utils.CheckValidationErrors(context, err, manualEntry) {
+		return
+	}
+
+	entry, err := manualEntry.Save(*taskId, context.GetInt64("userId"))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not save the time entry.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Time entry was saved successfully!",
+		"data":    entry,
+	})
+}
+
+func getTaskTimeEntries(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	entries, err := models.GetTaskTimeEntries(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the time entries.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    entries,
+	})
+}
+
+func deleteTimeEntry(context *gin.Context) {
+	entryId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Time entry id could not be parsed.",
+		})
+		return
+	}
+
+	entry, err := models.GetTimeEntry(*entryId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No time entry was found!",
+		})
+		return
+	}
+
+	// people can only remove their own logged time
+	if entry.UserID != context.GetInt64("userId") {
+		context.JSON(http.StatusForbidden, gin.H{
+			"message": "You can only delete your own time entries.",
+		})
+		return
+	}
+
+	err = entry.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the time entry.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Time entry was deleted successfully!",
+	})
+}
+
+// getTimeReport: /reports/time?group_by=user|category|date&from=2025-01-01&to=2025-02-01
+// from is inclusive and to is exclusive, both default to the last 30 days
+func getTimeReport(context *gin.Context) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+
+	var err error
+
+	if value := context.Query("from"); value != "" {
+		from, err = time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "from must be a date like 2006-01-02.",
+			})
+			return
+		}
+	}
+
+	if value := context.Query("to"); value != "" {
+		to, err = time.Parse(time.DateOnly, value)
+
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{
+				"message": "to must be a date like 2006-01-02.",
+			})
+			return
+		}
+	}
+
+	report, err := models.GetTimeReport(context.DefaultQuery("group_by", "user"), from, to)
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": err.Error(),
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    report,
+	})
+}
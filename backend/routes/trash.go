@@ -0,0 +1,185 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getTrash(context *gin.Context) {
+	tasks, err := models.GetDeletedTasks(time.Time{})
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the deleted tasks.",
+		})
+		return
+	}
+
+	categories, err := models.GetDeletedCategories(time.Time{})
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the deleted categories.",
+		})
+		return
+	}
+
+	trash := gin.H{
+		"tasks":      tasks,
+		"categories": categories,
+	}
+
+	// deleted users come with their emails and only admins can restore them, so only admins see them
+	isAdmin, err := models.IsAdmin(context.GetInt64("userId"))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not check the permissions.",
+		})
+		return
+	}
+
+	if isAdmin {
+		users, err := models.GetDeletedUsers(time.Time{})
+
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not get the deleted users.",
+			})
+			return
+		}
+
+		trash["users"] = users
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    trash,
+	})
+}
+
+func restoreTask(context *gin.Context) {
+	taskId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Task id could not be parsed.",
+		})
+		return
+	}
+
+	task, err := models.GetDeletedTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No deleted task was found!",
+		})
+		return
+	}
+
+	err = task.Restore(requestActor(context))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not restore the task.",
+		})
+		return
+	}
+
+	task, err = models.GetTask(*taskId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the restored task.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Task was restored successfully!",
+		"data":    task,
+	})
+}
+
+func restoreCategory(context *gin.Context) {
+	categoryId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Category id could not be parsed.",
+		})
+		return
+	}
+
+	category, err := models.GetDeletedCategory(*categoryId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No deleted category was found!",
+		})
+		return
+	}
+
+	err = category.Restore(requestActor(context))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not restore the category, the title may be taken by another category.",
+		})
+		return
+	}
+
+	category, err = models.GetCategory(*categoryId)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the restored category.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Category was restored successfully!",
+		"data":    category,
+	})
+}
+
+func restoreUser(context *gin.Context) {
+	userId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "User id could not be parsed.",
+		})
+		return
+	}
+
+	user, err := models.GetDeletedUser(*userId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No deleted user was found!",
+		})
+		return
+	}
+
+	err = user.Restore(requestActor(context))
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not restore the user, the email may be taken by another user.",
+		})
+		return
+	}
+
+	user.DeletedAt = nil
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "User was restored successfully!",
+		"data":    user,
+	})
+}
@@ -18,7 +18,7 @@ func signUpUser(context *gin.Context) {
 		return
 	}
 	// save the user in db
-	err = user.Save()
+	err = user.Save(requestActor(context))
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
@@ -102,7 +102,7 @@ func deleteUserAccount(context *gin.Context) {
 	}
 
 	// delete the user:
-	err = user.Delete()
+	err = user.Delete(requestActor(context))
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,228 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/abolfazlcodes/task-dashboard/backend/jobs"
+	"github.com/abolfazlcodes/task-dashboard/backend/models"
+	"github.com/abolfazlcodes/task-dashboard/backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func getWebhooks(context *gin.Context) {
+	webhooks, err := models.GetWebhooks(false)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the webhooks.",
+		})
+		return
+	}
+
+	// the secret is only shown once, when the webhook is created
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    webhooks,
+	})
+}
+
+func createWebhook(context *gin.Context) {
+	webhook := models.Webhook{Active: true}
+
+	err := context.ShouldBindJSON(&webhook)
+
+	if utils.CheckValidationErrors(context, err, webhook) || !checkWebhookEventTypes(context, webhook.EventTypes) {
+		return
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret, err = utils.GenerateRandomHex(32)
+
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Could not generate the webhook secret.",
+			})
+			return
+		}
+	}
+
+	err = webhook.Save()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not create the webhook.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook was created successfully!",
+		"data":    webhook,
+	})
+}
+
+// updateWebhook only changes the fields that were sent, sending a secret rotates it
+func updateWebhook(context *gin.Context) {
+	webhookId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Webhook id could not be parsed.",
+		})
+		return
+	}
+
+	webhook, err := models.GetWebhook(*webhookId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No webhook was found!",
+		})
+		return
+	}
+
+	err = context.ShouldBindJSON(webhook)
+
+	if utils.CheckValidationErrors(context, err, *webhook) || !checkWebhookEventTypes(context, webhook.EventTypes) {
+		return
+	}
+
+	webhook.ID = *webhookId
+
+	err = webhook.Update()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not update the webhook.",
+		})
+		return
+	}
+
+	webhook.Secret = ""
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Webhook was updated successfully!",
+		"data":    webhook,
+	})
+}
+
+func deleteWebhook(context *gin.Context) {
+	webhookId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Webhook id could not be parsed.",
+		})
+		return
+	}
+
+	webhook, err := models.GetWebhook(*webhookId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No webhook was found!",
+		})
+		return
+	}
+
+	err = webhook.Delete()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not delete the webhook.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "Webhook was deleted successfully!",
+	})
+}
+
+func getWebhookDeliveries(context *gin.Context) {
+	webhookId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Webhook id could not be parsed.",
+		})
+		return
+	}
+
+	limit, offset := parsePagination(context)
+
+	deliveries, err := models.GetWebhookDeliveries(*webhookId, limit, offset)
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not get the webhook deliveries.",
+		})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"message": "successful",
+		"data":    deliveries,
+	})
+}
+
+func redeliverWebhook(context *gin.Context) {
+	deliveryId, err := utils.ConvertStringToInt(context.Param("id"))
+
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Delivery id could not be parsed.",
+		})
+		return
+	}
+
+	delivery, err := models.GetWebhookDelivery(*deliveryId)
+
+	if err != nil {
+		context.JSON(http.StatusNotFound, gin.H{
+			"message": "No delivery was found!",
+		})
+		return
+	}
+
+	err = delivery.Redeliver()
+
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Could not queue the delivery.",
+		})
+		return
+	}
+
+	jobs.WakeWebhookWorker()
+
+	context.JSON(http.StatusAccepted, gin.H{
+		"message": "Delivery was queued again.",
+	})
+}
+
+func checkWebhookEventTypes(context *gin.Context, eventTypes []string) bool {
+	errorsOutput := make(map[string]string)
+
+	for _, eventType := range eventTypes {
+		if eventType != "*" && !slices.Contains(models.WebhookEventTypes, eventType) {
+			errorsOutput["event_types"] = fmt.Sprintf("%s is not an event type", eventType)
+		}
+	}
+
+	if len(errorsOutput) > 0 {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"message": "Request validation errors.",
+			"errors":  errorsOutput,
+		})
+		return false
+	}
+
+	return true
+}
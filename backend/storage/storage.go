@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage is where the attachment blobs live, the metadata stays in sqlite
+type Storage interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	// Open returns a seekable reader so downloads can answer Range requests
+	Open(key string) (io.ReadSeekCloser, error)
+	Delete(key string) error
+}
+
+var Store Storage
+
+func InitStorage() {
+	driver := os.Getenv("STORAGE_DRIVER")
+
+	switch driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+
+		if dir == "" {
+			dir = "uploads"
+		}
+
+		local, err := NewLocalStorage(dir)
+
+		if err != nil {
+			panic(fmt.Sprintf("Could not initialize local storage: %v", err))
+		}
+
+		Store = local
+	case "s3":
+		s3, err := NewS3Storage(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+
+		if err != nil {
+			panic(fmt.Sprintf("Could not initialize s3 storage: %v", err))
+		}
+
+		Store = s3
+	default:
+		panic(fmt.Sprintf("Unknown storage driver %q", driver))
+	}
+}
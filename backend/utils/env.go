@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+func GetEnvInt64(name string, fallback int64) int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+// comma separated env values, e.g. "image/png,image/jpeg"
+func GetEnvList(name string, fallback []string) []string {
+	value := os.Getenv(name)
+
+	if value == "" {
+		return fallback
+	}
+
+	var list []string
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// true, 1, false, 0 and the like, see strconv.ParseBool
+func GetEnvBool(name string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,419 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+)
+
+var ErrImageTooLarge = errors.New("image is too large")
+
+// StripImageMetadata drops EXIF / XMP / text metadata from jpeg, png and webp
+// files without re-encoding them, other types are returned untouched. The EXIF
+// orientation of a jpeg is kept, phone photos would be shown rotated otherwise
+func StripImageMetadata(data []byte, contentType string) []byte {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEGMetadata(data)
+	case "image/png":
+		return stripPNGMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data)
+	}
+
+	return data
+}
+
+func stripJPEGMetadata(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+
+	output := bytes.NewBuffer(make([]byte, 0, len(data)))
+	output.Write(data[:2])
+
+	position := 2
+
+	for position+4 <= len(data) {
+		if data[position] != 0xFF {
+			// broken file, keep whatever is left as is
+			output.Write(data[position:])
+			return output.Bytes()
+		}
+
+		marker := data[position+1]
+
+		// fill bytes before a marker
+		if marker == 0xFF {
+			position++
+			continue
+		}
+
+		// standalone markers have no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			output.Write(data[position : position+2])
+			position += 2
+			continue
+		}
+
+		// start of scan, the compressed image data follows
+		if marker == 0xDA {
+			output.Write(data[position:])
+			return output.Bytes()
+		}
+
+		segmentLength := int(binary.BigEndian.Uint16(data[position+2 : position+4]))
+		end := position + 2 + segmentLength
+
+		if segmentLength < 2 || end > len(data) {
+			output.Write(data[position:])
+			return output.Bytes()
+		}
+
+		// APP1 holds EXIF and XMP, APP13 holds IPTC and COM is a free text comment
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			output.Write(data[position:end])
+		} else if orientation := exifOrientation(data[position+4 : end]); orientation > 1 {
+			output.Write(orientationSegment(orientation))
+		}
+
+		position = end
+	}
+
+	output.Write(data[position:])
+
+	return output.Bytes()
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func stripPNGMetadata(data []byte) []byte {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return data
+	}
+
+	output := bytes.NewBuffer(make([]byte, 0, len(data)))
+	output.Write(pngSignature)
+
+	position := len(pngSignature)
+
+	for position+12 <= len(data) {
+		chunkLength := int(binary.BigEndian.Uint32(data[position : position+4]))
+		chunkType := string(data[position+4 : position+8])
+		end := position + 12 + chunkLength
+
+		if end > len(data) {
+			output.Write(data[position:])
+			return output.Bytes()
+		}
+
+		switch chunkType {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			output.Write(data[position:end])
+		}
+
+		position = end
+	}
+
+	output.Write(data[position:])
+
+	return output.Bytes()
+}
+
+func stripWebPMetadata(data []byte) []byte {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return data
+	}
+
+	output := bytes.NewBuffer(make([]byte, 0, len(data)))
+	output.Write(data[:12])
+
+	position := 12
+
+	for position+8 <= len(data) {
+		chunkType := string(data[position : position+4])
+		chunkLength := int(binary.LittleEndian.Uint32(data[position+4 : position+8]))
+		end := position + 8 + chunkLength + chunkLength%2
+
+		if end > len(data) {
+			output.Write(data[position:])
+			break
+		}
+
+		switch chunkType {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[position:end]...)
+
+			// clear the "has EXIF" and "has XMP" flags
+			if len(chunk) > 8 {
+				chunk[8] &^= 0x08 | 0x04
+			}
+
+			output.Write(chunk)
+		default:
+			output.Write(data[position:end])
+		}
+
+		position = end
+	}
+
+	stripped := output.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:8], uint32(len(stripped)-8))
+
+	return stripped
+}
+
+// FlattenImage draws the image on white so the transparent parts can be saved as jpeg,
+// it makes a full size copy so it is done once for all the sizes of ResizeToFit
+func FlattenImage(source image.Image) *image.RGBA {
+	bounds := source.Bounds()
+
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, source, bounds.Min, draw.Over)
+
+	return flat
+}
+
+// ResizeToFit scales a flattened image down (never up) so it fits in a size x size box
+func ResizeToFit(flat *image.RGBA, size int) *image.RGBA {
+	bounds := flat.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	targetWidth, targetHeight := width, height
+
+	if width > size || height > size {
+		if width >= height {
+			targetWidth = size
+			targetHeight = max(1, height*size/width)
+		} else {
+			targetHeight = size
+			targetWidth = max(1, width*size/height)
+		}
+	}
+
+	if targetWidth == width && targetHeight == height {
+		return flat
+	}
+
+	// box filter: every target pixel is the average of the source pixels it covers
+	target := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+
+	for y := 0; y < targetHeight; y++ {
+		y0 := bounds.Min.Y + y*height/targetHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/targetHeight)
+
+		for x := 0; x < targetWidth; x++ {
+			x0 := bounds.Min.X + x*width/targetWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/targetWidth)
+
+			var r, g, b, count uint32
+
+			for sy := y0; sy < y1; sy++ {
+				offset := flat.PixOffset(x0, sy)
+
+				for sx := x0; sx < x1; sx++ {
+					r += uint32(flat.Pix[offset])
+					g += uint32(flat.Pix[offset+1])
+					b += uint32(flat.Pix[offset+2])
+					offset += 4
+					count++
+				}
+			}
+
+			target.SetRGBA(x, y, color.RGBA{R: uint8(r / count), G: uint8(g / count), B: uint8(b / count), A: 0xFF})
+		}
+	}
+
+	return target
+}
+
+// DecodeImage decodes an image after checking its size from the header, a small file can
+// declare a huge image and decoding it would take all the memory. A jpeg is turned by its
+// EXIF orientation
+func DecodeImage(reader io.ReadSeeker, maxPixels int64) (image.Image, error) {
+	config, _, err := image.DecodeConfig(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the EXIF segment comes right after the start of a jpeg
+	head := make([]byte, 64<<10)
+	n, _ := io.ReadFull(reader, head)
+	orientation := JPEGOrientation(head[:n])
+
+	_, err = reader.Seek(0, io.SeekStart)
+
+	if err != nil {
+		return nil, err
+	}
+
+	source, _, err := image.Decode(reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ApplyOrientation(source, orientation), nil
+}
+
+// JPEGOrientation returns the EXIF orientation of a jpeg from 1 to 8, 1 when it has none
+func JPEGOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	position := 2
+
+	for position+4 <= len(data) && data[position] == 0xFF {
+		marker := data[position+1]
+
+		if marker == 0xDA {
+			break
+		}
+
+		end := position + 2 + int(binary.BigEndian.Uint16(data[position+2:position+4]))
+
+		if end > len(data) {
+			break
+		}
+
+		if marker == 0xE1 {
+			if orientation := exifOrientation(data[position+4 : end]); orientation > 0 {
+				return orientation
+			}
+		}
+
+		position = end
+	}
+
+	return 1
+}
+
+// exifOrientation reads the orientation tag of the first IFD of an APP1 payload, 0 when there is none
+func exifOrientation(payload []byte) int {
+	if len(payload) < 14 || string(payload[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+
+	tiff := payload[6:]
+
+	var order binary.ByteOrder
+
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+
+	if offset+2 > len(tiff) {
+		return 0
+	}
+
+	count := int(order.Uint16(tiff[offset : offset+2]))
+
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+
+		if entry+12 > len(tiff) {
+			return 0
+		}
+
+		// 0x0112 is the orientation, a SHORT kept in the value field
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+
+			if orientation < 1 || orientation > 8 {
+				return 0
+			}
+
+			return orientation
+		}
+	}
+
+	return 0
+}
+
+// orientationSegment is an APP1 segment with an EXIF block holding only the orientation
+func orientationSegment(orientation int) []byte {
+	return []byte{
+		0xFF, 0xE1, 0x00, 0x22,
+		'E', 'x', 'i', 'f', 0x00, 0x00,
+		// big endian tiff header with the first IFD at offset 8
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08,
+		// one entry: tag 0x0112, type SHORT, count 1, the value
+		0x00, 0x01,
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, byte(orientation), 0x00, 0x00,
+		// no next IFD
+		0x00, 0x00, 0x00, 0x00,
+	}
+}
+
+// ApplyOrientation turns and mirrors the image so it shows upright, orientation is the EXIF value
+func ApplyOrientation(source image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return source
+	}
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// 5 to 8 swap the sides
+	targetWidth, targetHeight := width, height
+
+	if orientation >= 5 {
+		targetWidth, targetHeight = height, width
+	}
+
+	target := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var tx, ty int
+
+			switch orientation {
+			case 2:
+				tx, ty = width-1-x, y
+			case 3:
+				tx, ty = width-1-x, height-1-y
+			case 4:
+				tx, ty = x, height-1-y
+			case 5:
+				tx, ty = y, x
+			case 6:
+				tx, ty = height-1-y, x
+			case 7:
+				tx, ty = height-1-y, width-1-x
+			case 8:
+				tx, ty = y, width-1-x
+			}
+
+			target.Set(tx, ty, source.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return target
+}
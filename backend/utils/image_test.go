@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// a 2x1 image, red on the left and blue on the right
+func twoPixels() image.Image {
+	source := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	source.Set(0, 0, color.RGBA{R: 255, A: 255})
+	source.Set(1, 0, color.RGBA{B: 255, A: 255})
+
+	return source
+}
+
+func TestApplyOrientation(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+
+	// where the red pixel ends up and the size after turning
+	tests := []struct {
+		orientation   int
+		width, height int
+		redX, redY    int
+	}{
+		{1, 2, 1, 0, 0},
+		{2, 2, 1, 1, 0},
+		{3, 2, 1, 1, 0},
+		{4, 2, 1, 0, 0},
+		{5, 1, 2, 0, 0},
+		{6, 1, 2, 0, 0},
+		{7, 1, 2, 0, 1},
+		{8, 1, 2, 0, 1},
+	}
+
+	for _, test := range tests {
+		turned := ApplyOrientation(twoPixels(), test.orientation)
+		bounds := turned.Bounds()
+
+		if bounds.Dx() != test.width || bounds.Dy() != test.height {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", test.orientation, bounds.Dx(), bounds.Dy(), test.width, test.height)
+			continue
+		}
+
+		if got := color.RGBAModel.Convert(turned.At(test.redX, test.redY)); got != red {
+			t.Errorf("orientation %d: pixel at %d,%d is %v, want red", test.orientation, test.redX, test.redY, got)
+		}
+	}
+}
+
+func encodeJPEG(t *testing.T) []byte {
+	var buffer bytes.Buffer
+
+	err := jpeg.Encode(&buffer, twoPixels(), nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// withSegment puts a segment right after the start of the jpeg
+func withSegment(data, segment []byte) []byte {
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestStripJPEGMetadataKeepsOrientation(t *testing.T) {
+	// an EXIF block in little endian with a make tag before the orientation
+	exif := []byte("Exif\x00\x00II\x2a\x00\x08\x00\x00\x00\x02\x00" +
+		"\x0f\x01\x02\x00\x04\x00\x00\x00ACME" +
+		"\x12\x01\x03\x00\x01\x00\x00\x00\x06\x00\x00\x00" +
+		"\x00\x00\x00\x00")
+	segment := append([]byte{0xFF, 0xE1, 0x00, byte(len(exif) + 2)}, exif...)
+
+	data := withSegment(encodeJPEG(t), segment)
+
+	if got := JPEGOrientation(data); got != 6 {
+		t.Fatalf("orientation before stripping = %d, want 6", got)
+	}
+
+	stripped := StripImageMetadata(data, "image/jpeg")
+
+	if bytes.Contains(stripped, []byte("ACME")) {
+		t.Fatal("the other EXIF tags were not stripped")
+	}
+
+	if got := JPEGOrientation(stripped); got != 6 {
+		t.Fatalf("orientation after stripping = %d, want 6", got)
+	}
+
+	source, err := DecodeImage(bytes.NewReader(stripped), 100)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bounds := source.Bounds(); bounds.Dx() != 1 || bounds.Dy() != 2 {
+		t.Fatalf("decoded size %dx%d, want the turned 1x2", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestStripJPEGMetadataWithoutOrientation(t *testing.T) {
+	data := encodeJPEG(t)
+	payload := []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmp := append([]byte{0xFF, 0xE1, 0x00, byte(len(payload) + 2)}, payload...)
+
+	stripped := StripImageMetadata(withSegment(data, xmp), "image/jpeg")
+
+	if !bytes.Equal(stripped, data) {
+		t.Fatal("the XMP segment was not dropped")
+	}
+}
+
+func TestDecodeImageRejectsHugeImages(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := png.Encode(&buffer, twoPixels())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// make the header declare 100000x100000 and fix the checksum of the IHDR chunk
+	data := buffer.Bytes()
+	binary.BigEndian.PutUint32(data[16:20], 100000)
+	binary.BigEndian.PutUint32(data[20:24], 100000)
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err = DecodeImage(bytes.NewReader(data), 40_000_000)
+
+	if !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("DecodeImage = %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestResizeToFit(t *testing.T) {
+	// a transparent 400x100 image with an opaque black pixel in the corner
+	source := image.NewNRGBA(image.Rect(0, 0, 400, 100))
+	source.Set(0, 0, color.NRGBA{A: 255})
+
+	flat := FlattenImage(source)
+
+	if got := flat.RGBAAt(399, 99); got != (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+		t.Errorf("transparent pixel flattened to %v, want white", got)
+	}
+
+	tests := []struct {
+		size   int
+		width  int
+		height int
+	}{
+		{100, 100, 25},
+		{200, 200, 50},
+		{1000, 400, 100},
+	}
+
+	for _, test := range tests {
+		resized := ResizeToFit(flat, test.size)
+
+		if resized.Bounds().Dx() != test.width || resized.Bounds().Dy() != test.height {
+			t.Errorf("ResizeToFit(%d) = %v, want %dx%d", test.size, resized.Bounds(), test.width, test.height)
+		}
+	}
+}
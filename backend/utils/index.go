@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"strconv"
 	"strings"
@@ -22,6 +24,39 @@ func ConvertStringToInt(value string) (*int64, error) {
 	return &numInt, nil
 }
 
+// ParseIDList parses comma separated ids like "1,2,3" from query strings
+func ParseIDList(value string) ([]int64, error) {
+	var ids []int64
+
+	if value == "" {
+		return ids, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		id, err := ConvertStringToInt(strings.TrimSpace(part))
+
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, *id)
+	}
+
+	return ids, nil
+}
+
+func GenerateRandomHex(length int) (string, error) {
+	bytes := make([]byte, length)
+
+	_, err := rand.Read(bytes)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bytes), nil
+}
+
 type Option struct {
 	Value       int64  `json:"value"`
 	Label       string `json:"label"`
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// rank digits in ascii order so ranks compare correctly as plain strings in sqlite too
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var ErrInvalidRankRange = errors.New("the lower rank has to sort before the upper rank")
+
+// RankBetween returns a rank that sorts between lower and upper, an empty lower means
+// the start and an empty upper the end. A new rank is found without touching any other
+// row, the ranks just get a digit longer when the gap runs out
+func RankBetween(lower, upper string) (string, error) {
+	if upper != "" && lower >= upper {
+		return "", ErrInvalidRankRange
+	}
+
+	return rankMidpoint(lower, upper), nil
+}
+
+// rankMidpoint is the fractional indexing midpoint, upper == "" stands for infinity
+func rankMidpoint(lower, upper string) string {
+	if upper != "" {
+		// keep the common prefix, a missing digit of lower counts as the zero digit
+		n := 0
+
+		for n < len(upper) && rankDigitAt(lower, n) == upper[n] {
+			n++
+		}
+
+		if n > 0 {
+			rest := ""
+
+			if len(lower) > n {
+				rest = lower[n:]
+			}
+
+			return upper[:n] + rankMidpoint(rest, upper[n:])
+		}
+	}
+
+	lowerDigit := 0
+
+	if lower != "" {
+		lowerDigit = strings.IndexByte(rankDigits, lower[0])
+	}
+
+	upperDigit := len(rankDigits)
+
+	if upper != "" {
+		upperDigit = strings.IndexByte(rankDigits, upper[0])
+	}
+
+	if upperDigit-lowerDigit > 1 {
+		return string(rankDigits[(lowerDigit+upperDigit+1)/2])
+	}
+
+	// the first digits are neighbours, the first digit of a longer upper is already in between
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+
+	rest := ""
+
+	if lower != "" {
+		rest = lower[1:]
+	}
+
+	return string(rankDigits[lowerDigit]) + rankMidpoint(rest, "")
+}
+
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+
+	return rankDigits[0]
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		lower, upper string
+		want         string
+	}{
+		{"", "", "V"},
+		{"", "V", "G"},
+		{"V", "", "l"},
+		{"a", "c", "b"},
+		{"a", "b", "aV"},
+		{"a", "a1", "a0V"},
+		{"az", "b", "azV"},
+		{"", "1", "0V"},
+		{"y", "", "z"},
+		{"z", "", "zV"},
+		{"aV", "b", "al"},
+		{"a", "aV", "aG"},
+	}
+
+	for _, test := range tests {
+		got, err := RankBetween(test.lower, test.upper)
+
+		if err != nil {
+			t.Errorf("RankBetween(%q, %q) = %v", test.lower, test.upper, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("RankBetween(%q, %q) = %q, want %q", test.lower, test.upper, got, test.want)
+		}
+	}
+}
+
+func TestRankBetweenInvalidRange(t *testing.T) {
+	tests := []struct {
+		lower, upper string
+	}{
+		{"b", "a"},
+		{"a", "a"},
+		{"aV", "a"},
+	}
+
+	for _, test := range tests {
+		_, err := RankBetween(test.lower, test.upper)
+
+		if !errors.Is(err, ErrInvalidRankRange) {
+			t.Errorf("RankBetween(%q, %q) = %v, want ErrInvalidRankRange", test.lower, test.upper, err)
+		}
+	}
+}
+
+// the ranks of a board where cards keep being put at the top, at the bottom and in between
+// must stay in order and never end in the zero digit, nothing could go before such a rank
+func TestRankBetweenKeepsOrder(t *testing.T) {
+	ranks := []string{}
+
+	insert := func(index int) {
+		lower, upper := "", ""
+
+		if index > 0 {
+			lower = ranks[index-1]
+		}
+
+		if index < len(ranks) {
+			upper = ranks[index]
+		}
+
+		rank, err := RankBetween(lower, upper)
+
+		if err != nil {
+			t.Fatalf("RankBetween(%q, %q) = %v", lower, upper, err)
+		}
+
+		if rank <= lower || (upper != "" && rank >= upper) {
+			t.Fatalf("RankBetween(%q, %q) = %q, not in between", lower, upper, rank)
+		}
+
+		if strings.HasSuffix(rank, rankDigits[:1]) {
+			t.Fatalf("RankBetween(%q, %q) = %q ends in the zero digit", lower, upper, rank)
+		}
+
+		ranks = append(ranks[:index], append([]string{rank}, ranks[index:]...)...)
+	}
+
+	for i := 0; i < 300; i++ {
+		switch i % 3 {
+		case 0:
+			insert(0)
+		case 1:
+			insert(len(ranks))
+		default:
+			insert(len(ranks) / 2)
+		}
+	}
+
+	// always between the same two neighbours, the ranks only get longer
+	for i := 0; i < 100; i++ {
+		insert(1)
+	}
+}
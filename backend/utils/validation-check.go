@@ -16,35 +16,9 @@ func CheckValidationErrors(context *gin.Context, err error, model interface{}) b
 		return false
 	}
 
-	var validationErrors validator.ValidationErrors
-
-	if errors.As(err, &validationErrors) {
-		errorsOutput := make(map[string]string)
-
-		reflected := reflect.TypeOf(model)
-
-		for _, fe := range validationErrors {
-			field, ok := reflected.FieldByName(fe.StructField())
-
-			if !ok {
-				continue
-			}
-
-			jsonTag := field.Tag.Get("json")
-			fieldName := strings.Split(jsonTag, ",")[0]
-
-			switch fe.Tag() {
-			case "required":
-				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s is required", fieldName)
-			case "min":
-				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s must be at least %s characters", fieldName, fe.Param())
-			case "email":
-				errorsOutput[strings.ToLower(fieldName)] = "Invalid email format."
-			default:
-				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s is not valid", fieldName)
-			}
-		}
+	errorsOutput, ok := ValidationErrorMessages(err, model)
 
+	if ok {
 		context.JSON(http.StatusBadRequest, gin.H{
 			"message": "Request validation errors.",
 			"errors":  errorsOutput,
@@ -58,3 +32,51 @@ func CheckValidationErrors(context *gin.Context, err error, model interface{}) b
 	})
 	return true
 }
+
+// ValidationErrorMessages turns the binding errors into messages by json field name,
+// ok is false when err is not a validation error
+func ValidationErrorMessages(err error, model interface{}) (map[string]string, bool) {
+	var validationErrors validator.ValidationErrors
+
+	if !errors.As(err, &validationErrors) {
+		return nil, false
+	}
+
+	errorsOutput := make(map[string]string)
+
+	reflected := reflect.TypeOf(model)
+
+	for _, fe := range validationErrors {
+		field, ok := reflected.FieldByName(fe.StructField())
+
+		if !ok {
+			continue
+		}
+
+		jsonTag := field.Tag.Get("json")
+		fieldName := strings.Split(jsonTag, ",")[0]
+
+		switch fe.Tag() {
+		case "required":
+			errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s is required", fieldName)
+		case "min":
+			if fe.Kind() == reflect.String {
+				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s must be at least %s characters", fieldName, fe.Param())
+			} else {
+				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s must be at least %s", fieldName, fe.Param())
+			}
+		case "max":
+			if fe.Kind() == reflect.String {
+				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s must be at most %s characters", fieldName, fe.Param())
+			} else {
+				errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s must be at most %s", fieldName, fe.Param())
+			}
+		case "email":
+			errorsOutput[strings.ToLower(fieldName)] = "Invalid email format."
+		default:
+			errorsOutput[strings.ToLower(fieldName)] = fmt.Sprintf("%s is not valid", fieldName)
+		}
+	}
+
+	return errorsOutput, true
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// the parts of a workbook that don't depend on the rows
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`},
+	// style 1 is the bold font of the header row
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`},
+}
+
+// XLSXWriter writes a workbook of one sheet row by row, so the rows never have to be
+// held in memory. The first row is the header and is made bold
+type XLSXWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+func NewXLSXWriter(w io.Writer) (*XLSXWriter, error) {
+	archive := zip.NewWriter(w)
+
+	for _, part := range xlsxParts {
+		file, err := archive.Create(part.name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = io.WriteString(file, part.content)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the sheet has to be the last part, the rows are written straight into it
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := bufio.NewWriter(file)
+
+	_, err = sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &XLSXWriter{archive: archive, sheet: sheet}, nil
+}
+
+// WriteRow adds a row, numbers become number cells and everything else text cells
+func (writer *XLSXWriter) WriteRow(cells []any) error {
+	writer.rows++
+
+	style := ""
+
+	if writer.rows == 1 {
+		style = ` s="1"`
+	}
+
+	var row bytes.Buffer
+
+	fmt.Fprintf(&row, `<row r="%d">`, writer.rows)
+
+	for i, cell := range cells {
+		reference := xlsxColumn(i) + strconv.Itoa(writer.rows)
+
+		switch value := cell.(type) {
+		case nil:
+			continue
+		case int64:
+			fmt.Fprintf(&row, `<c r="%s"%s><v>%d</v></c>`, reference, style, value)
+		case float64:
+			fmt.Fprintf(&row, `<c r="%s"%s><v>%s</v></c>`, reference, style, strconv.FormatFloat(value, 'f', -1, 64))
+		default:
+			fmt.Fprintf(&row, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, reference, style)
+			xml.EscapeText(&row, []byte(fmt.Sprint(value)))
+			row.WriteString(`</t></is></c>`)
+		}
+	}
+
+	row.WriteString(`</row>`)
+
+	_, err := writer.sheet.Write(row.Bytes())
+
+	return err
+}
+
+// Flush pushes the written rows to the underlying writer
+func (writer *XLSXWriter) Flush() error {
+	err := writer.sheet.Flush()
+
+	if err != nil {
+		return err
+	}
+
+	return writer.archive.Flush()
+}
+
+// Close ends the sheet and the archive, it doesn't close the underlying writer
+func (writer *XLSXWriter) Close() error {
+	_, err := writer.sheet.WriteString(`</sheetData></worksheet>`)
+
+	if err != nil {
+		return err
+	}
+
+	err = writer.sheet.Flush()
+
+	if err != nil {
+		return err
+	}
+
+	return writer.archive.Close()
+}
+
+// xlsxColumn gives the letters of a column index, 0 is A and 26 is AA
+func xlsxColumn(index int) string {
+	name := ""
+
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
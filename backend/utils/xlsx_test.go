@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXLSXColumn(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"},
+		{1, "B"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+		{16383, "XFD"},
+	}
+
+	for _, test := range tests {
+		if got := xlsxColumn(test.index); got != test.want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", test.index, got, test.want)
+		}
+	}
+}
+
+func TestXLSXWriterSheet(t *testing.T) {
+	var buffer bytes.Buffer
+
+	writer, err := NewXLSXWriter(&buffer)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := [][]any{
+		{"title", "estimate"},
+		{"a <b> & c", 1.5},
+		{nil, int64(3)},
+	}
+
+	for _, row := range rows {
+		err = writer.WriteRow(row)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = writer.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sheet, err := archive.Open("xl/worksheets/sheet1.xml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := io.ReadAll(sheet)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">title</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">a &lt;b&gt; &amp; c</t></is></c>`,
+		`<c r="B2"><v>1.5</v></c>`,
+		`<row r="3"><c r="B3"><v>3</v></c></row>`,
+		`</sheetData></worksheet>`,
+	}
+
+	for _, part := range want {
+		if !strings.Contains(string(data), part) {
+			t.Errorf("the sheet does not contain %s:\n%s", part, data)
+		}
+	}
+}